@@ -2,7 +2,10 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/gommon/log"
@@ -23,8 +26,52 @@ type AppConfig struct {
 		Port     string
 		Password string
 	}
+	Scheduler struct {
+		Interval time.Duration
+	}
+	Trash struct {
+		Retention     time.Duration
+		PurgeInterval time.Duration
+	}
+	Views struct {
+		DedupWindow   time.Duration
+		FlushInterval time.Duration
+	}
+	Auth struct {
+		Secret          string
+		AccessTokenTTL  time.Duration
+		RefreshTokenTTL time.Duration
+	}
+	RateLimit struct {
+		Key         string
+		ReadLimit   int
+		ReadWindow  time.Duration
+		WriteLimit  int
+		WriteWindow time.Duration
+		Groups      map[string]RateLimitRates
+		LoginLimit  int
+		LoginWindow time.Duration
+		AuthLimit   int
+		AuthWindow  time.Duration
+	}
+	Locale struct {
+		Default   string
+		Supported []string
+	}
+}
+
+// RateLimitRates are the read and write limits of a group of routes
+type RateLimitRates struct {
+	ReadLimit   int
+	ReadWindow  time.Duration
+	WriteLimit  int
+	WriteWindow time.Duration
 }
 
+// rateLimitGroups are the groups of routes whose limits can be set apart,
+// e.g. with RATE_LIMIT_NEWS_WRITE
+var rateLimitGroups = []string{"news", "tags", "apikeys"}
+
 var lock = &sync.Mutex{}
 var appConfig *AppConfig
 
@@ -58,6 +105,85 @@ func initConfig() *AppConfig {
 	defaultConfig.Redis.Host = os.Getenv("REDIS_HOST")
 	defaultConfig.Redis.Port = os.Getenv("REDIS_PORT")
 	defaultConfig.Redis.Password = os.Getenv("REDIS_PASSWORD")
+	defaultConfig.Scheduler.Interval = getDuration("SCHEDULER_INTERVAL", time.Minute)
+	defaultConfig.Trash.Retention = getDuration("TRASH_RETENTION", 30*24*time.Hour)
+	defaultConfig.Trash.PurgeInterval = getDuration("TRASH_PURGE_INTERVAL", time.Hour)
+	defaultConfig.Views.DedupWindow = getDuration("VIEW_DEDUP_WINDOW", 30*time.Minute)
+	defaultConfig.Views.FlushInterval = getDuration("VIEW_FLUSH_INTERVAL", time.Minute)
+	defaultConfig.Auth.Secret = os.Getenv("JWT_SECRET")
+	defaultConfig.Auth.AccessTokenTTL = getDuration("JWT_ACCESS_TTL", 15*time.Minute)
+	defaultConfig.Auth.RefreshTokenTTL = getDuration("JWT_REFRESH_TTL", 7*24*time.Hour)
+	defaultConfig.RateLimit.Key = os.Getenv("RATE_LIMIT_KEY")
+	defaultConfig.RateLimit.ReadLimit, defaultConfig.RateLimit.ReadWindow = getRate("RATE_LIMIT_READ", 300, time.Minute)
+	defaultConfig.RateLimit.WriteLimit, defaultConfig.RateLimit.WriteWindow = getRate("RATE_LIMIT_WRITE", 60, time.Minute)
+	defaultConfig.RateLimit.Groups = map[string]RateLimitRates{}
+	for _, group := range rateLimitGroups {
+		var rates RateLimitRates
+		prefix := "RATE_LIMIT_" + strings.ToUpper(group)
+		rates.ReadLimit, rates.ReadWindow = getRate(prefix+"_READ", defaultConfig.RateLimit.ReadLimit, defaultConfig.RateLimit.ReadWindow)
+		rates.WriteLimit, rates.WriteWindow = getRate(prefix+"_WRITE", defaultConfig.RateLimit.WriteLimit, defaultConfig.RateLimit.WriteWindow)
+		defaultConfig.RateLimit.Groups[group] = rates
+	}
+	defaultConfig.RateLimit.LoginLimit, defaultConfig.RateLimit.LoginWindow = getRate("RATE_LIMIT_LOGIN", 10, time.Minute)
+	defaultConfig.RateLimit.AuthLimit, defaultConfig.RateLimit.AuthWindow = getRate("RATE_LIMIT_AUTH", 600, time.Minute)
+	defaultConfig.Locale.Default = getString("LOCALE_DEFAULT", "id")
+	defaultConfig.Locale.Supported = getList("LOCALES", []string{"id", "en"})
 
 	return &defaultConfig
 }
+
+func getString(key string, fallback string) string {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+// getList reads a comma separated list
+func getList(key string, fallback []string) []string {
+	list := []string{}
+
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+
+	if len(list) == 0 {
+		return fallback
+	}
+
+	return list
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	duration, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || duration <= 0 {
+		return fallback
+	}
+
+	return duration
+}
+
+// getRate reads a rate written as requests/window, e.g. 60/1m. A limit of 0
+// turns the rate limit off.
+func getRate(key string, fallbackLimit int, fallbackWindow time.Duration) (int, time.Duration) {
+	parts := strings.SplitN(os.Getenv(key), "/", 2)
+	if len(parts) != 2 {
+		return fallbackLimit, fallbackWindow
+	}
+
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit < 0 {
+		return fallbackLimit, fallbackWindow
+	}
+
+	window, err := time.ParseDuration(parts[1])
+	if err != nil || window <= 0 {
+		return fallbackLimit, fallbackWindow
+	}
+
+	return limit, window
+}
@@ -0,0 +1,93 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	anonymousActor = "anonymous"
+	identityKey    = "identity"
+)
+
+// Identity is who made a request, set by the auth middleware. Requests made
+// with an API key carry the key's scopes instead of a role.
+type Identity struct {
+	TenantID uint
+	UserID   uint
+	Username string
+	Role     entity.Role
+	APIKeyID uint
+	Scopes   []entity.Scope
+}
+
+// Can reports whether the role, or the scopes of an API key, grant a permission
+func (i Identity) Can(permission entity.Permission) bool {
+	if i.APIKeyID == 0 {
+		return i.Role.Can(permission)
+	}
+
+	for _, scope := range i.Scopes {
+		if scope.Can(permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func SetIdentity(c echo.Context, identity Identity) {
+	c.Set(identityKey, identity)
+}
+
+// CurrentIdentity returns the identity of an authenticated request
+func CurrentIdentity(c echo.Context) (Identity, bool) {
+	identity, ok := c.Get(identityKey).(Identity)
+	return identity, ok
+}
+
+// Actor returns the name recorded as the editor of changes made by a request
+func Actor(c echo.Context) string {
+	if identity, ok := CurrentIdentity(c); ok {
+		return identity.Username
+	}
+
+	return anonymousActor
+}
+
+// Authorize fails with ErrForbidden unless the request may take an action
+func Authorize(c echo.Context, permission entity.Permission) error {
+	identity, ok := CurrentIdentity(c)
+	if !ok {
+		return fmt.Errorf("%w: authentication required", entity.ErrUnauthorized)
+	}
+
+	if !identity.Can(permission) {
+		return fmt.Errorf("%w: %s is not allowed to %s", entity.ErrForbidden, identity.Username, permission)
+	}
+
+	return nil
+}
+
+// BearerToken returns the token of the `Authorization: Bearer ...` header
+func BearerToken(c echo.Context) (string, bool) {
+	return authorization(c, "Bearer")
+}
+
+// APIKey returns the key of the `Authorization: ApiKey ...` header
+func APIKey(c echo.Context) (string, bool) {
+	return authorization(c, "ApiKey")
+}
+
+func authorization(c echo.Context, scheme string) (string, bool) {
+	auth := c.Request().Header.Get(echo.HeaderAuthorization)
+
+	if !strings.HasPrefix(auth, scheme+" ") {
+		return "", false
+	}
+
+	return strings.TrimPrefix(auth, scheme+" "), true
+}
@@ -0,0 +1,172 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/labstack/echo/v4"
+)
+
+type ResponseError struct {
+	Code    int                 `json:"code"`
+	Message string              `json:"message"`
+	Errors  []entity.FieldError `json:"errors,omitempty"`
+	Data    interface{}         `json:"data,omitempty"`
+}
+
+// ErrorHandler renders every error returned by a handler as a ResponseError
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	response := NewResponseError(err)
+
+	if response.Code == http.StatusInternalServerError {
+		c.Logger().Error(err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(response.Code)
+	} else {
+		err = c.JSON(response.Code, response)
+	}
+
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+func NewResponseError(err error) ResponseError {
+	var httpErr *echo.HTTPError
+	var validationErr entity.ValidationError
+	var unknownTagErr entity.UnknownTagError
+	var versionErr entity.VersionConflictError
+
+	switch {
+	case errors.As(err, &validationErr):
+		return ResponseError{
+			Code:    http.StatusBadRequest,
+			Message: http.StatusText(http.StatusBadRequest),
+			Errors:  validationErr.Fields,
+		}
+	case errors.As(err, &unknownTagErr):
+		fields := []entity.FieldError{}
+
+		for _, id := range unknownTagErr.IDs {
+			fields = append(fields, entity.FieldError{
+				Field:   "tags",
+				Message: fmt.Sprintf("tag %d does not exist", id),
+			})
+		}
+
+		for _, name := range unknownTagErr.Names {
+			fields = append(fields, entity.FieldError{
+				Field:   "tag_names",
+				Message: fmt.Sprintf("tag %q does not exist", name),
+			})
+		}
+
+		return ResponseError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: http.StatusText(http.StatusUnprocessableEntity),
+			Errors:  fields,
+		}
+	case errors.Is(err, entity.ErrNotFound):
+		return ResponseError{
+			Code:    http.StatusNotFound,
+			Message: http.StatusText(http.StatusNotFound),
+		}
+	case errors.As(err, &versionErr):
+		return ResponseError{
+			Code:    http.StatusConflict,
+			Message: err.Error(),
+			Data:    map[string]uint{"current_version": versionErr.Current},
+		}
+	case errors.Is(err, entity.ErrConflict):
+		return ResponseError{
+			Code:    http.StatusConflict,
+			Message: err.Error(),
+		}
+	case errors.Is(err, entity.ErrUnauthorized):
+		return ResponseError{
+			Code:    http.StatusUnauthorized,
+			Message: err.Error(),
+		}
+	case errors.Is(err, entity.ErrForbidden):
+		return ResponseError{
+			Code:    http.StatusForbidden,
+			Message: err.Error(),
+		}
+	case errors.As(err, &httpErr):
+		return ResponseError{
+			Code:    httpErr.Code,
+			Message: fmt.Sprint(httpErr.Message),
+		}
+	}
+
+	return ResponseError{
+		Code:    http.StatusInternalServerError,
+		Message: http.StatusText(http.StatusInternalServerError),
+	}
+}
+
+// ParamID parses a numeric path parameter
+func ParamID(c echo.Context, name string) (int, error) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		return id, entity.ValidationError{Fields: []entity.FieldError{{
+			Field:   name,
+			Message: name + " must be a number",
+		}}}
+	}
+
+	return id, nil
+}
+
+// QueryLimit reads the limit query param, between 1 and 100, or returns
+// fallback when it is not given
+func QueryLimit(c echo.Context, fallback int) (int, error) {
+	if c.QueryParam("limit") == "" {
+		return fallback, nil
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 || limit > 100 {
+		return 0, entity.ValidationError{Fields: []entity.FieldError{{
+			Field:   "limit",
+			Message: "limit must be a number between 1 and 100",
+		}}}
+	}
+
+	return limit, nil
+}
+
+// ExpectedVersion returns the version a write is based on, read from the
+// If-Match header or else from the version field of the request body
+func ExpectedVersion(c echo.Context, bodyVersion uint) (uint, error) {
+	if ifMatch := c.Request().Header.Get("If-Match"); ifMatch != "" {
+		version, err := strconv.ParseUint(strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`), 10, 64)
+		if err != nil {
+			return 0, entity.ValidationError{Fields: []entity.FieldError{{
+				Field:   "If-Match",
+				Message: "If-Match must be a version number",
+			}}}
+		}
+
+		return uint(version), nil
+	}
+
+	if bodyVersion == 0 {
+		return 0, entity.ValidationError{Fields: []entity.FieldError{{
+			Field:   "version",
+			Message: "version is required, in the body or the If-Match header",
+		}}}
+	}
+
+	return bodyVersion, nil
+}
@@ -0,0 +1,27 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Vary adds the request headers a response depends on to its Vary header,
+// leaving out the ones it already lists
+func Vary(c echo.Context, headers ...string) {
+	header := c.Response().Header()
+
+	listed := map[string]bool{}
+	for _, value := range header.Values(echo.HeaderVary) {
+		for _, name := range strings.Split(value, ",") {
+			listed[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+	}
+
+	for _, name := range headers {
+		if !listed[strings.ToLower(name)] {
+			header.Add(echo.HeaderVary, name)
+			listed[strings.ToLower(name)] = true
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package common
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	defaultLocale    = "id"
+	supportedLocales = []string{"id", "en"}
+)
+
+// ConfigureLocales sets the locale news and tags are written in and the
+// locales they may be translated to
+func ConfigureLocales(fallback string, supported []string) {
+	defaultLocale = strings.ToLower(fallback)
+	supportedLocales = []string{defaultLocale}
+
+	for _, locale := range supported {
+		if locale = strings.ToLower(locale); !SupportedLocale(locale) {
+			supportedLocales = append(supportedLocales, locale)
+		}
+	}
+}
+
+// DefaultLocale returns the locale news and tags are written in
+func DefaultLocale() string {
+	return defaultLocale
+}
+
+func SupportedLocale(locale string) bool {
+	for _, supported := range supportedLocales {
+		if supported == locale {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Locale returns the locale a request asks for with ?lang=, or else the
+// preferred supported locale of its Accept-Language header, or else the
+// default locale
+func Locale(c echo.Context) (string, error) {
+	if lang := c.QueryParam("lang"); lang != "" {
+		locale := strings.ToLower(lang)
+
+		if !SupportedLocale(locale) {
+			return "", entity.ValidationError{Fields: []entity.FieldError{{
+				Field:   "lang",
+				Message: "lang must be one of " + strings.Join(supportedLocales, ", "),
+			}}}
+		}
+
+		return locale, nil
+	}
+
+	for _, locale := range acceptedLanguages(c.Request().Header.Get("Accept-Language")) {
+		if SupportedLocale(locale) {
+			return locale, nil
+		}
+	}
+
+	return defaultLocale, nil
+}
+
+// TranslationLocale checks a locale is one news and tags may be translated to
+func TranslationLocale(locale string) (string, error) {
+	locale = strings.ToLower(locale)
+
+	if locale == defaultLocale || !SupportedLocale(locale) {
+		return "", entity.ValidationError{Fields: []entity.FieldError{{
+			Field:   "locale",
+			Message: "locale must be one of " + strings.Join(supportedLocales[1:], ", "),
+		}}}
+	}
+
+	return locale, nil
+}
+
+// acceptedLanguages returns the languages of an Accept-Language header, e.g.
+// "en-US,en;q=0.9,id;q=0.8", without their region and most preferred first
+func acceptedLanguages(header string) []string {
+	type language struct {
+		name    string
+		quality float64
+	}
+
+	languages := []language{}
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if i := strings.IndexAny(name, "-_"); i >= 0 {
+			name = name[:i]
+		}
+
+		if name == "" || name == "*" {
+			continue
+		}
+
+		quality := 1.0
+
+		for _, param := range fields[1:] {
+			if value := strings.TrimSpace(param); strings.HasPrefix(value, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(value, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if quality > 0 {
+			languages = append(languages, language{name: name, quality: quality})
+		}
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	names := []string{}
+	for _, language := range languages {
+		names = append(names, language.name)
+	}
+
+	return names
+}
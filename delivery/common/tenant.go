@@ -0,0 +1,19 @@
+package common
+
+import (
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/labstack/echo/v4"
+)
+
+const tenantKey = "tenant"
+
+func SetTenant(c echo.Context, tenant entity.Tenant) {
+	c.Set(tenantKey, tenant)
+}
+
+// TenantID returns the id of the tenant of a request, set by the tenant
+// middleware, or 0 when no tenant was resolved
+func TenantID(c echo.Context) uint {
+	tenant, _ := c.Get(tenantKey).(entity.Tenant)
+	return tenant.ID
+}
@@ -1,10 +1,13 @@
 package common
 
 import (
-	"net/http"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 
+	"github.com/furqonzt99/news-redis/domain/entity"
 	"github.com/go-playground/validator/v10"
-	"github.com/labstack/echo/v4"
 )
 
 type Validator struct {
@@ -13,8 +16,59 @@ type Validator struct {
 
 func (v *Validator) Validate(i interface{}) error {
 	if err := v.Validator.Struct(i); err != nil {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			return err
+		}
 
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		fields := []entity.FieldError{}
+
+		for _, fieldErr := range validationErrors {
+			name := jsonFieldName(i, fieldErr.StructField())
+
+			fields = append(fields, entity.FieldError{
+				Field:   name,
+				Message: fieldMessage(i, name, fieldErr),
+			})
+		}
+
+		return entity.ValidationError{Fields: fields}
 	}
 	return nil
 }
+
+// jsonFieldName returns the json name of a request field, so errors match the payload
+func jsonFieldName(i interface{}, field string) string {
+	t := reflect.TypeOf(i)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Struct {
+		if structField, ok := t.FieldByName(field); ok {
+			name := strings.Split(structField.Tag.Get("json"), ",")[0]
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+	}
+
+	return strings.ToLower(field)
+}
+
+func fieldMessage(i interface{}, name string, fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return name + " is required"
+	case "required_without":
+		return name + " is required when " + jsonFieldName(i, fieldErr.Param()) + " is empty"
+	case "oneof":
+		return name + " must be one of: " + fieldErr.Param()
+	case "min":
+		return name + " must be at least " + fieldErr.Param()
+	case "max":
+		return name + " must be at most " + fieldErr.Param()
+	}
+
+	return fmt.Sprintf("%s is invalid (%s)", name, fieldErr.Tag())
+}
@@ -0,0 +1,137 @@
+package apikeys
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/furqonzt99/news-redis/services"
+	"github.com/labstack/echo/v4"
+)
+
+type APIKeyController struct {
+	Repository repository.APIKeyInterface
+}
+
+func NewAPIKeyController(repository repository.APIKeyInterface) *APIKeyController {
+	return &APIKeyController{Repository: repository}
+}
+
+// scoped returns the repository of the tenant of the request, keys belong to
+// the tenant they are created on
+func (kc APIKeyController) scoped(c echo.Context) repository.APIKeyInterface {
+	return kc.Repository.ForTenant(common.TenantID(c))
+}
+
+// Create issues a key. The key is only part of this response, it cannot be
+// read again afterwards.
+func (kc APIKeyController) Create(c echo.Context) error {
+	var keyRequest CreateAPIKeyRequest
+
+	if err := c.Bind(&keyRequest); err != nil {
+		return err
+	}
+
+	if err := c.Validate(&keyRequest); err != nil {
+		return err
+	}
+
+	fields := []entity.FieldError{}
+	scopes := []entity.Scope{}
+
+	for _, name := range keyRequest.Scopes {
+		scope := entity.Scope(name)
+
+		if !scope.Valid() {
+			fields = append(fields, entity.FieldError{
+				Field:   "scopes",
+				Message: "scope " + name + " does not exist",
+			})
+		}
+
+		scopes = append(scopes, scope)
+	}
+
+	if keyRequest.ExpiresAt != nil && !keyRequest.ExpiresAt.After(time.Now()) {
+		fields = append(fields, entity.FieldError{
+			Field:   "expires_at",
+			Message: "expires_at must be in the future",
+		})
+	}
+
+	if len(fields) > 0 {
+		return entity.ValidationError{Fields: fields}
+	}
+
+	key, err := services.GenerateAPIKey()
+	if err != nil {
+		return err
+	}
+
+	apiKey := entity.APIKey{
+		Name:      keyRequest.Name,
+		Prefix:    services.APIKeyPrefix(key),
+		Hash:      services.HashAPIKey(key),
+		CreatedBy: common.Actor(c),
+		ExpiresAt: keyRequest.ExpiresAt,
+	}
+	apiKey.SetScopes(scopes)
+
+	apiKey, err = kc.scoped(c).Create(apiKey)
+	if err != nil {
+		return err
+	}
+
+	response := toAPIKeyResponse(apiKey)
+	response.Key = key
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
+}
+
+func (kc APIKeyController) ReadAll(c echo.Context) error {
+	keysDB, err := kc.scoped(c).ReadAll()
+	if err != nil {
+		return err
+	}
+
+	response := []APIKeyResponse{}
+
+	for _, apiKey := range keysDB {
+		response = append(response, toAPIKeyResponse(apiKey))
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
+}
+
+func (kc APIKeyController) Revoke(c echo.Context) error {
+	keyID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := kc.scoped(c).Revoke(keyID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+}
+
+func toAPIKeyResponse(apiKey entity.APIKey) APIKeyResponse {
+	scopes := []string{}
+	for _, scope := range apiKey.ScopeList() {
+		scopes = append(scopes, string(scope))
+	}
+
+	return APIKeyResponse{
+		ID:        int(apiKey.ID),
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    scopes,
+		CreatedBy: apiKey.CreatedBy,
+		CreatedAt: apiKey.CreatedAt,
+		ExpiresAt: apiKey.ExpiresAt,
+		RevokedAt: apiKey.RevokedAt,
+	}
+}
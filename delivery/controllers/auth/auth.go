@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/furqonzt99/news-redis/services"
+	"github.com/furqonzt99/news-redis/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// dummyPassword is checked when the username is unknown, so a login takes
+// as long whether the user exists or not
+const dummyPassword = "$2a$10$7EqJtq98hPqEX7fNZaFWoOhi5BWX4Z8aKmaR.gx3iuvnEFrfMS2MG"
+
+var errInvalidCredentials = fmt.Errorf("%w: invalid username or password", entity.ErrUnauthorized)
+
+type AuthController struct {
+	Repository repository.UserInterface
+}
+
+func NewAuthController(repository repository.UserInterface) *AuthController {
+	return &AuthController{Repository: repository}
+}
+
+// scoped returns the repository of the tenant of the request, users may only
+// log in to their own tenant
+func (ac AuthController) scoped(c echo.Context) repository.UserInterface {
+	return ac.Repository.ForTenant(common.TenantID(c))
+}
+
+func (ac AuthController) Login(c echo.Context) error {
+	var loginRequest LoginRequest
+
+	if err := c.Bind(&loginRequest); err != nil {
+		return err
+	}
+
+	if err := c.Validate(&loginRequest); err != nil {
+		return err
+	}
+
+	user, err := ac.scoped(c).ReadByUsername(loginRequest.Username)
+	if err != nil && !errors.Is(err, entity.ErrNotFound) {
+		return err
+	}
+
+	if err != nil {
+		utils.CheckPassword(dummyPassword, loginRequest.Password)
+		return errInvalidCredentials
+	}
+
+	if !utils.CheckPassword(user.Password, loginRequest.Password) {
+		return errInvalidCredentials
+	}
+
+	return issueTokens(c, user)
+}
+
+// Refresh exchanges a refresh token for a new pair of tokens. The refresh
+// token can only be used once.
+func (ac AuthController) Refresh(c echo.Context) error {
+	var refreshRequest RefreshRequest
+
+	if err := c.Bind(&refreshRequest); err != nil {
+		return err
+	}
+
+	if err := c.Validate(&refreshRequest); err != nil {
+		return err
+	}
+
+	claims, err := services.ParseToken(refreshRequest.RefreshToken, services.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := services.RevokeToken(claims)
+	if err != nil {
+		return err
+	}
+
+	if !revoked {
+		return fmt.Errorf("%w: token has been revoked", entity.ErrUnauthorized)
+	}
+
+	user, err := ac.scoped(c).ReadOne(int(claims.UserID()))
+	if errors.Is(err, entity.ErrNotFound) {
+		return errInvalidCredentials
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return issueTokens(c, user)
+}
+
+// Logout revokes the access token of the request, and the refresh token when given
+func (ac AuthController) Logout(c echo.Context) error {
+	var logoutRequest LogoutRequest
+
+	if err := c.Bind(&logoutRequest); err != nil {
+		return err
+	}
+
+	token, _ := common.BearerToken(c)
+
+	claims, err := services.ParseToken(token, services.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	if _, err := services.RevokeToken(claims); err != nil {
+		return err
+	}
+
+	if logoutRequest.RefreshToken != "" {
+		refreshClaims, err := services.ParseToken(logoutRequest.RefreshToken, services.RefreshToken)
+		if err != nil {
+			return err
+		}
+
+		if refreshClaims.Subject != claims.Subject {
+			return fmt.Errorf("%w: refresh token belongs to another user", entity.ErrUnauthorized)
+		}
+
+		if _, err := services.RevokeToken(refreshClaims); err != nil {
+			return err
+		}
+	}
+
+	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+}
+
+func issueTokens(c echo.Context, user entity.User) error {
+	tokens, err := services.IssueTokens(user)
+	if err != nil {
+		return err
+	}
+
+	response := TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(tokens.ExpiresIn.Seconds()),
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
+}
@@ -0,0 +1,180 @@
+package news
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/labstack/echo/v4"
+)
+
+// newsRelations maps what include= can embed in a news response to the
+// response field holding it
+var newsRelations = map[string]string{
+	"tags":   "tags",
+	"author": "authors",
+}
+
+// newsIncludes are the values of include=
+var newsIncludes = []string{"author", "tags"}
+
+// newsFields are the fields of a news response which fields= can choose,
+// relations are chosen with include= instead
+var newsFields = attributeFields(reflect.TypeOf(newsResponse{}))
+
+// projection is the part of news responses a request asks for. Fields are
+// chosen with fields=, e.g. fields=id,title,summary, and relations with
+// include=, e.g. include=tags. No fields means all of them, and without
+// include= every relation is embedded.
+type projection struct {
+	Fields  []string
+	Include []string
+}
+
+// readProjection reads fields= and include=. extraFields are the fields an
+// endpoint adds to news responses, e.g. window_views.
+func readProjection(c echo.Context, extraFields ...string) (projection, error) {
+	allowed := append(append([]string{}, newsFields...), extraFields...)
+
+	p := projection{Include: newsIncludes}
+
+	var err error
+
+	p.Fields, err = queryList(c.QueryParam("fields"), allowed, "fields")
+	if err != nil {
+		return p, err
+	}
+
+	if c.QueryParams().Has("include") {
+		p.Include, err = queryList(c.QueryParam("include"), newsIncludes, "include")
+		if err != nil {
+			return p, err
+		}
+	}
+
+	return p, nil
+}
+
+// String keys the cache of a projection
+func (p projection) String() string {
+	return "fields=" + strings.Join(p.Fields, ",") + "&include=" + strings.Join(p.Include, ",")
+}
+
+// complete reports whether the projection has the whole response
+func (p projection) complete() bool {
+	return len(p.Fields) == 0 && len(p.Include) == len(newsRelations)
+}
+
+// apply keeps the chosen part of a news response, or of a list of them
+func (p projection) apply(response interface{}) interface{} {
+	if p.complete() {
+		return response
+	}
+
+	encoded, _ := json.Marshal(response)
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+
+	if reflect.ValueOf(response).Kind() == reflect.Slice {
+		items := []map[string]interface{}{}
+		_ = decoder.Decode(&items)
+
+		for _, item := range items {
+			p.trim(item)
+		}
+
+		return items
+	}
+
+	item := map[string]interface{}{}
+	_ = decoder.Decode(&item)
+
+	p.trim(item)
+
+	return item
+}
+
+func (p projection) trim(item map[string]interface{}) {
+	included := map[string]bool{}
+	for _, relation := range p.Include {
+		included[newsRelations[relation]] = true
+	}
+
+	for field := range item {
+		if isRelation(field) {
+			if !included[field] {
+				delete(item, field)
+			}
+		} else if len(p.Fields) > 0 && !containsString(p.Fields, field) {
+			delete(item, field)
+		}
+	}
+}
+
+// queryList parses a comma separated query parameter whose values must be
+// some of the allowed ones. The values are sorted, so the same choice always
+// makes the same cache key.
+func queryList(value string, allowed []string, name string) ([]string, error) {
+	values := []string{}
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+
+		if !containsString(allowed, v) {
+			return nil, entity.ValidationError{Fields: []entity.FieldError{{
+				Field:   name,
+				Message: name + " must be some of " + strings.Join(allowed, ", "),
+			}}}
+		}
+
+		if !containsString(values, v) {
+			values = append(values, v)
+		}
+	}
+
+	sort.Strings(values)
+
+	return values, nil
+}
+
+func isRelation(field string) bool {
+	for _, relation := range newsRelations {
+		if relation == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// attributeFields returns the JSON names of the fields of a struct, leaving
+// out relations
+func attributeFields(t reflect.Type) []string {
+	fields := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+
+		if name != "" && name != "-" && !isRelation(name) {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,9 +2,12 @@ package news
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/furqonzt99/news-redis/delivery/common"
 	"github.com/furqonzt99/news-redis/domain/entity"
@@ -14,6 +17,7 @@ import (
 )
 
 var newsEntity string = "news"
+var tagEntity string = "tag"
 
 type NewsController struct {
 	Repository repository.NewsInterface
@@ -23,26 +27,76 @@ func NewNewsController(repository repository.NewsInterface) *NewsController {
 	return &NewsController{Repository: repository}
 }
 
+// scoped returns the repository of the tenant of the request
+func (nc NewsController) scoped(c echo.Context) repository.NewsInterface {
+	return nc.Repository.ForTenant(common.TenantID(c))
+}
+
 func (nc NewsController) Create(c echo.Context) error {
 	var newsRequest CreateNewsRequest
 
-	c.Bind(&newsRequest)
+	if err := c.Bind(&newsRequest); err != nil {
+		return err
+	}
 
 	if err := c.Validate(&newsRequest); err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
+	}
+
+	if err := validateSchedule(newsRequest.PublishAt, newsRequest.UnpublishAt); err != nil {
+		return err
+	}
+
+	// a scheduled news is published or unpublished without anyone's review
+	if newsRequest.PublishAt != nil || newsRequest.UnpublishAt != nil {
+		if err := common.Authorize(c, entity.PermNewsPublish); err != nil {
+			return err
+		}
+	}
+
+	// creating tags on the fly is managing tags
+	if newsRequest.CreateTags {
+		if err := common.Authorize(c, entity.PermTagsManage); err != nil {
+			return err
+		}
 	}
 
 	news := entity.News{
-		Title: newsRequest.Title,
-		Body:  newsRequest.Body,
+		Title:       newsRequest.Title,
+		Body:        newsRequest.Body,
+		BodyFormat:  entity.BodyFormat(newsRequest.BodyFormat),
+		Summary:     newsRequest.Summary,
+		PublishAt:   newsRequest.PublishAt,
+		UnpublishAt: newsRequest.UnpublishAt,
+		EditedBy:    common.Actor(c),
+	}
+
+	tags := entity.TagSelection{
+		IDs:           newsRequest.Tags,
+		Names:         newsRequest.TagNames,
+		CreateMissing: newsRequest.CreateTags,
+	}
+
+	// the user creating a news is one of its authors
+	authorIDs := newsRequest.Authors
+	currentIDs := []uint{}
+	if identity, ok := common.CurrentIdentity(c); ok && identity.UserID != 0 {
+		authorIDs = append([]uint{identity.UserID}, authorIDs...)
+		currentIDs = append(currentIDs, identity.UserID)
 	}
 
-	_, err := nc.Repository.Create(news, newsRequest.Tags)
+	if err := authorizeAuthors(c, currentIDs, authorIDs); err != nil {
+		return err
+	}
+
+	newsDB, err := nc.scoped(c).Create(news, tags, authorIDs)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
 	}
 
-	go services.DeleteCache(newsEntity)
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+
+	nc.refreshTags(c, newsDB.Tags)
 
 	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
 }
@@ -50,187 +104,811 @@ func (nc NewsController) Create(c echo.Context) error {
 func (nc NewsController) ReadAll(c echo.Context) error {
 
 	newsFilter := entity.NewsFilter{
-		Status: c.QueryParam("status"),
-		Tags:   strings.Split(c.QueryParam("topic"), ","),
+		Status:          entity.NewsStatus(c.QueryParam("status")),
+		Tags:            strings.Split(c.QueryParam("topic"), ","),
+		IncludeChildren: c.QueryParam("include_children") == "true",
+		Author:          c.QueryParam("author"),
+	}
+
+	if newsFilter.Status != "" && !newsFilter.Status.Valid() {
+		return entity.ValidationError{Fields: []entity.FieldError{{
+			Field:   "status",
+			Message: "status " + string(newsFilter.Status) + " does not exist",
+		}}}
 	}
 
+	// anonymous readers only see published news
+	view := readView(c)
+	if view == publicView {
+		if newsFilter.Status != "" && newsFilter.Status != entity.StatusPublish {
+			return common.Authorize(c, entity.PermNewsReadAny)
+		}
+
+		newsFilter.Status = entity.StatusPublish
+	}
+
+	locale, err := common.Locale(c)
+	if err != nil {
+		return err
+	}
+
+	projection, err := readProjection(c)
+	if err != nil {
+		return err
+	}
+
+	cacheFilter := newsListCacheKey{View: view, Locale: locale, Projection: projection.String(), Filter: newsFilter}
+
 	response := []newsResponse{}
 
-	// get data from cache
-	newsCache, err := services.GetCache(newsEntity, 0, newsFilter)
+	// get data from cache, already projected
+	newsCache, err := services.GetCache(common.TenantID(c), newsEntity, 0, cacheFilter)
 	if err == nil {
-		// Unmarshal response
-		_ = json.Unmarshal([]byte(newsCache), &response)
-		return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "cache"))
+		return c.JSON(http.StatusOK, common.SuccessResponseWithData(json.RawMessage(newsCache), "cache"))
 	}
 
-	newsDB, err := nc.Repository.ReadAll(newsFilter)
+	newsDB, err := nc.scoped(c).ReadAll(newsFilter)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
 	}
 
 	for _, news := range newsDB {
 		if len(news.Tags) > 0 {
+			response = append(response, toNewsResponse(news, locale))
+		}
+	}
+
+	projected := projection.apply(response)
+
+	// Marshal response
+	resMarshal, _ := json.Marshal(projected)
+
+	// Create cache
+	go services.CreateCache(common.TenantID(c), newsEntity, 0, cacheFilter, resMarshal)
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(projected, "database"))
+}
+
+func (nc NewsController) ReadOne(c echo.Context) error {
+
+	newsID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	view := readView(c)
+
+	locale, err := common.Locale(c)
+	if err != nil {
+		return err
+	}
+
+	projection, err := readProjection(c)
+	if err != nil {
+		return err
+	}
+
+	cacheFilter := string(view) + ":" + locale + ":" + projection.String()
+
+	cached := cachedNews{}
+
+	// get data from cache
+	newsCache, err := services.GetCache(common.TenantID(c), newsEntity, newsID, cacheFilter)
+	if err == nil {
+		// Unmarshal response
+		_ = json.Unmarshal([]byte(newsCache), &cached)
+		countView(c, newsID, cached.Status)
+		return c.JSON(http.StatusOK, common.SuccessResponseWithData(cached.Response, "cache"))
+	}
+
+	newsDB, err := nc.scoped(c).ReadOne(newsID)
+	if err != nil {
+		return err
+	}
+
+	if !view.Shows(newsDB) {
+		return entity.ErrNotFound
+	}
+
+	countView(c, newsID, string(newsDB.Status))
+
+	projected := projection.apply(toNewsResponse(newsDB, locale))
+
+	cached.Status = string(newsDB.Status)
+	cached.Response, _ = json.Marshal(projected)
+
+	// Marshal response
+	resMarshal, _ := json.Marshal(cached)
+
+	// Create cache
+	go services.CreateCache(common.TenantID(c), newsEntity, newsID, cacheFilter, resMarshal)
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(projected, "database"))
+}
+
+// ReadTrending lists the published news most viewed during a window of time
+func (nc NewsController) ReadTrending(c echo.Context) error {
+	window := 24 * time.Hour
+
+	if c.QueryParam("window") != "" {
+		var err error
+
+		window, err = time.ParseDuration(c.QueryParam("window"))
+		if err != nil || window < time.Hour || window > services.MaxTrendingWindow {
+			return entity.ValidationError{Fields: []entity.FieldError{{
+				Field:   "window",
+				Message: "window must be a duration between 1h and " + services.MaxTrendingWindow.String(),
+			}}}
+		}
+	}
+
+	limit, err := common.QueryLimit(c, 10)
+	if err != nil {
+		return err
+	}
+
+	locale, err := common.Locale(c)
+	if err != nil {
+		return err
+	}
+
+	projection, err := readProjection(c, "window_views")
+	if err != nil {
+		return err
+	}
+
+	response := []trendingResponse{}
+
+	// the views count news which are no longer published, they are skipped
+	// and more are read until the limit is filled
+	batch := int64(2 * limit)
+
+	for offset := int64(0); len(response) < limit; offset += batch {
+		trending, err := services.ReadTrending(common.TenantID(c), window, offset, batch)
+		if err != nil {
+			return err
+		}
+
+		if len(trending) == 0 {
+			break
+		}
+
+		newsIDs := []uint{}
+		for _, views := range trending {
+			newsIDs = append(newsIDs, views.NewsID)
+		}
+
+		newsDB, err := nc.scoped(c).ReadByIDs(newsIDs)
+		if err != nil {
+			return err
+		}
 
-			tags := []string{}
+		newsByID := map[uint]entity.News{}
+		for _, news := range newsDB {
+			newsByID[news.ID] = news
+		}
 
-			for _, tag := range news.Tags {
-				tags = append(tags, tag.Name)
+		for _, views := range trending {
+			news, ok := newsByID[views.NewsID]
+			if !ok || news.Status != entity.StatusPublish || len(response) == limit {
+				continue
 			}
 
-			response = append(response, newsResponse{
-				ID:     int(news.ID),
-				Title:  news.Title,
-				Body:   news.Body,
-				Status: news.Status,
-				Tags:   tags,
+			response = append(response, trendingResponse{
+				newsResponse: toNewsResponse(news, locale),
+				WindowViews:  views.Views,
 			})
 		}
+
+		if int64(len(trending)) < batch {
+			break
+		}
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(projection.apply(response), "cache"))
+}
+
+// ReadBySlug looks a news up by its slug. Former slugs are redirected to the
+// current one, so old links keep working.
+func (nc NewsController) ReadBySlug(c echo.Context) error {
+	slug := c.Param("slug")
+	view := readView(c)
+
+	locale, err := common.Locale(c)
+	if err != nil {
+		return err
+	}
+
+	projection, err := readProjection(c)
+	if err != nil {
+		return err
+	}
+
+	cacheFilter := string(view) + ":" + locale + ":" + projection.String() + ":slug:" + slug
+
+	// get data from cache, already projected
+	newsCache, err := services.GetCache(common.TenantID(c), newsEntity, 0, cacheFilter)
+	if err == nil {
+		return c.JSON(http.StatusOK, common.SuccessResponseWithData(json.RawMessage(newsCache), "cache"))
 	}
 
+	newsDB, err := nc.scoped(c).ReadBySlug(slug)
+	if err != nil {
+		return err
+	}
+
+	if !view.Shows(newsDB) {
+		return entity.ErrNotFound
+	}
+
+	// the query keeps asking for the same locale and fields
+	if newsDB.Slug != slug {
+		location := "/news/slug/" + url.PathEscape(newsDB.Slug)
+		if query := c.Request().URL.RawQuery; query != "" {
+			location += "?" + query
+		}
+
+		return c.Redirect(http.StatusMovedPermanently, location)
+	}
+
+	projected := projection.apply(toNewsResponse(newsDB, locale))
+
 	// Marshal response
-	resMarshal, _ := json.Marshal(response)
+	resMarshal, _ := json.Marshal(projected)
 
 	// Create cache
-	go services.CreateCache(newsEntity, 0, newsFilter, resMarshal)
+	go services.CreateCache(common.TenantID(c), newsEntity, 0, cacheFilter, resMarshal)
 
-	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(projected, "database"))
 }
 
-func (nc NewsController) ReadOne(c echo.Context) error {
+// ReadRelated lists published news sharing the most tags with a news
+func (nc NewsController) ReadRelated(c echo.Context) error {
+	newsID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
 
-	newsID, err := strconv.Atoi(c.Param("id"))
+	limit, err := common.QueryLimit(c, 5)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
 	}
 
-	response := newsResponse{}
+	view := readView(c)
 
-	// get data from cache
-	newsCache, err := services.GetCache(newsEntity, newsID, "")
+	locale, err := common.Locale(c)
+	if err != nil {
+		return err
+	}
+
+	projection, err := readProjection(c)
+	if err != nil {
+		return err
+	}
+
+	cacheFilter := "related:" + string(view) + ":" + locale + ":" + strconv.Itoa(limit) + ":" + projection.String()
+
+	response := []newsResponse{}
+
+	// get data from cache, already projected
+	newsCache, err := services.GetCache(common.TenantID(c), newsEntity, newsID, cacheFilter)
 	if err == nil {
-		// Unmarshal response
-		_ = json.Unmarshal([]byte(newsCache), &response)
-		return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "cache"))
+		return c.JSON(http.StatusOK, common.SuccessResponseWithData(json.RawMessage(newsCache), "cache"))
 	}
 
-	newsDB, err := nc.Repository.ReadOne(newsID)
+	// the related news would tell a news outside the view exists, and its tags
+	source, err := nc.scoped(c).ReadOne(newsID)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		return err
 	}
 
-	tags := []string{}
+	if !view.Shows(source) {
+		return entity.ErrNotFound
+	}
 
-	for _, tag := range newsDB.Tags {
-		tags = append(tags, tag.Name)
+	newsDB, err := nc.scoped(c).ReadRelated(newsID, limit)
+	if err != nil {
+		return err
 	}
 
-	response = newsResponse{
-		ID:     newsID,
-		Title:  newsDB.Title,
-		Body:   newsDB.Body,
-		Status: newsDB.Status,
-		Tags:   tags,
+	for _, news := range newsDB {
+		response = append(response, toNewsResponse(news, locale))
 	}
 
+	projected := projection.apply(response)
+
 	// Marshal response
-	resMarshal, _ := json.Marshal(response)
+	resMarshal, _ := json.Marshal(projected)
 
 	// Create cache
-	go services.CreateCache(newsEntity, newsID, "", resMarshal)
+	go services.CreateCache(common.TenantID(c), newsEntity, newsID, cacheFilter, resMarshal)
 
-	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(projected, "database"))
 }
 
 func (nc NewsController) Edit(c echo.Context) error {
-	newsID, err := strconv.Atoi(c.Param("id"))
+	newsID, err := common.ParamID(c, "id")
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
 	}
 
 	var newsRequest UpdateNewsRequest
 
-	c.Bind(&newsRequest)
+	if err := c.Bind(&newsRequest); err != nil {
+		return err
+	}
 
 	if err := c.Validate(&newsRequest); err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
+	}
+
+	schedule := entity.ScheduleChange{
+		PublishAt:      newsRequest.PublishAt.Time(),
+		UnpublishAt:    newsRequest.UnpublishAt.Time(),
+		SetPublishAt:   newsRequest.PublishAt.Set(),
+		SetUnpublishAt: newsRequest.UnpublishAt.Set(),
+	}
+
+	if err := validateSchedule(schedule.PublishAt, schedule.UnpublishAt); err != nil {
+		return err
+	}
+
+	// a scheduled news is published or unpublished without anyone's review
+	if schedule.SetPublishAt || schedule.SetUnpublishAt {
+		if err := common.Authorize(c, entity.PermNewsPublish); err != nil {
+			return err
+		}
+	}
+
+	if newsRequest.Authors != nil && len(newsRequest.Authors) == 0 {
+		return entity.ValidationError{Fields: []entity.FieldError{{
+			Field:   "authors",
+			Message: "a news needs at least one author",
+		}}}
+	}
+
+	// creating tags on the fly is managing tags
+	if newsRequest.CreateTags {
+		if err := common.Authorize(c, entity.PermTagsManage); err != nil {
+			return err
+		}
 	}
 
 	news := entity.News{
-		Title: newsRequest.Title,
-		Body:  newsRequest.Body,
+		Title:      newsRequest.Title,
+		Body:       newsRequest.Body,
+		BodyFormat: entity.BodyFormat(newsRequest.BodyFormat),
+		Summary:    newsRequest.Summary,
+		EditedBy:   common.Actor(c),
 	}
 
-	_, err = nc.Repository.Edit(newsID, news, newsRequest.Tags)
+	news.Version, err = common.ExpectedVersion(c, newsRequest.Version)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		return err
 	}
 
-	go services.DeleteCache(newsEntity)
+	tags := entity.TagSelection{
+		IDs:           newsRequest.Tags,
+		Names:         newsRequest.TagNames,
+		CreateMissing: newsRequest.CreateTags,
+	}
+
+	oldNews, err := nc.scoped(c).ReadOne(newsID)
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeNews(c, oldNews, entity.PermNewsWrite); err != nil {
+		return err
+	}
+
+	currentIDs := []uint{}
+	for _, author := range oldNews.Authors {
+		currentIDs = append(currentIDs, author.ID)
+	}
+
+	if err := authorizeAuthors(c, currentIDs, newsRequest.Authors); err != nil {
+		return err
+	}
+
+	newsDB, err := nc.scoped(c).Edit(newsID, news, tags, newsRequest.Authors, schedule)
+	if err != nil {
+		return err
+	}
+
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+
+	nc.refreshTags(c, oldNews.Tags, newsDB.Tags)
 
 	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
 }
 
 func (nc NewsController) Delete(c echo.Context) error {
-	newsID, err := strconv.Atoi(c.Param("id"))
+	newsID, err := common.ParamID(c, "id")
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
+	}
+
+	var newsDB entity.News
+
+	if c.QueryParam("hard") == "true" {
+		if err := common.Authorize(c, entity.PermNewsPurge); err != nil {
+			return err
+		}
+
+		newsDB, err = nc.scoped(c).Purge(newsID)
+	} else {
+		newsDB, err = nc.scoped(c).Delete(newsID)
 	}
 
-	_, err = nc.Repository.Delete(newsID)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		return err
 	}
 
-	go services.DeleteCache(newsEntity)
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+
+	nc.refreshTags(c, newsDB.Tags)
 
 	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
 }
 
 func (nc NewsController) SetStatusDeleted(c echo.Context) error {
-	newsID, err := strconv.Atoi(c.Param("id"))
+	newsID, err := common.ParamID(c, "id")
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
 	}
 
-	_, err = nc.Repository.SetStatusDeleted(newsID)
+	newsDB, err := nc.authorizeTransition(c, newsID, entity.StatusDeleted)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		return err
 	}
 
-	go services.DeleteCache(newsEntity)
+	_, err = nc.scoped(c).SetStatusDeleted(newsID, common.Actor(c))
+	if err != nil {
+		return err
+	}
+
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+
+	nc.refreshTags(c, newsDB.Tags)
 
 	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
 }
 
 func (nc NewsController) SetStatusPublish(c echo.Context) error {
-	newsID, err := strconv.Atoi(c.Param("id"))
+	newsID, err := common.ParamID(c, "id")
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
 	}
 
-	_, err = nc.Repository.SetStatusPublish(newsID)
+	newsDB, err := nc.authorizeTransition(c, newsID, entity.StatusPublish)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		return err
 	}
 
-	go services.DeleteCache(newsEntity)
+	_, err = nc.scoped(c).SetStatusPublish(newsID, common.Actor(c))
+	if err != nil {
+		return err
+	}
+
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+
+	nc.refreshTags(c, newsDB.Tags)
 
 	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
 }
 
 func (nc NewsController) SetStatusDraft(c echo.Context) error {
-	newsID, err := strconv.Atoi(c.Param("id"))
+	newsID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	newsDB, err := nc.authorizeTransition(c, newsID, entity.StatusDraft)
+	if err != nil {
+		return err
+	}
+
+	_, err = nc.scoped(c).SetStatusDraft(newsID, common.Actor(c))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
 	}
 
-	_, err = nc.Repository.SetStatusDraft(newsID)
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+
+	nc.refreshTags(c, newsDB.Tags)
+
+	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+}
+
+func (nc NewsController) SetStatus(c echo.Context) error {
+	newsID, err := common.ParamID(c, "id")
 	if err != nil {
-		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		return err
 	}
 
-	go services.DeleteCache(newsEntity)
+	var statusRequest StatusRequest
+
+	if err := c.Bind(&statusRequest); err != nil {
+		return err
+	}
+
+	if err := c.Validate(&statusRequest); err != nil {
+		return err
+	}
+
+	newsDB, err := nc.authorizeTransition(c, newsID, entity.NewsStatus(statusRequest.Status))
+	if err != nil {
+		return err
+	}
+
+	_, err = nc.scoped(c).SetStatus(newsID, entity.NewsStatus(statusRequest.Status), common.Actor(c))
+	if err != nil {
+		return err
+	}
+
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+
+	nc.refreshTags(c, newsDB.Tags)
+
+	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+}
+
+func (nc NewsController) ReadStatusHistory(c echo.Context) error {
+	newsID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	historyDB, err := nc.scoped(c).ReadStatusHistory(newsID)
+	if err != nil {
+		return err
+	}
+
+	response := []statusHistoryResponse{}
+
+	for _, history := range historyDB {
+		response = append(response, statusHistoryResponse{
+			From:      string(history.FromStatus),
+			To:        string(history.ToStatus),
+			ChangedAt: history.CreatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
+}
+
+// newsView is which news a request may read. The public view only has the
+// published news, the editorial view the news in every status.
+type newsView string
+
+const (
+	publicView    newsView = "public"
+	editorialView newsView = "editorial"
+)
+
+// newsListCacheKey keeps the cached lists of each view apart, so news only
+// the editorial view has never end up in a public response
+type newsListCacheKey struct {
+	View       newsView
+	Locale     string
+	Projection string
+	Filter     entity.NewsFilter
+}
+
+// cachedNews is a cached news response with the status of the news, which
+// views are counted by even when fields= leaves it out of the response
+type cachedNews struct {
+	Status   string          `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+func readView(c echo.Context) newsView {
+	if common.Authorize(c, entity.PermNewsReadAny) == nil {
+		return editorialView
+	}
+
+	return publicView
+}
+
+// Shows reports whether a news is part of the view
+func (v newsView) Shows(news entity.News) bool {
+	return v == editorialView || news.Status == entity.StatusPublish
+}
+
+// authorizeTransition checks the request may move a news from its current
+// status to the given one, and returns the news
+func (nc NewsController) authorizeTransition(c echo.Context, newsID int, status entity.NewsStatus) (entity.News, error) {
+	news, err := nc.scoped(c).ReadOne(newsID)
+	if err != nil {
+		return news, err
+	}
+
+	return news, authorizeNews(c, news, entity.TransitionPermission(news.Status, status))
+}
+
+// authorizeNews checks the request may take an action on a news. Writers may
+// only edit the news they are an author of, and only while it is a draft or in
+// review, editing a published news changes what readers see.
+func authorizeNews(c echo.Context, news entity.News, permission entity.Permission) error {
+	if err := common.Authorize(c, permission); err != nil {
+		return err
+	}
+
+	if permission == entity.PermNewsWrite && news.Status != entity.StatusDraft && news.Status != entity.StatusReview {
+		if err := common.Authorize(c, entity.PermNewsPublish); err != nil {
+			return err
+		}
+	}
+
+	if permission != entity.PermNewsWrite || common.Authorize(c, entity.PermNewsEditAny) == nil {
+		return nil
+	}
+
+	identity, _ := common.CurrentIdentity(c)
+
+	if !news.IsAuthor(identity.UserID) {
+		return fmt.Errorf("%w: only the authors of this news can edit it", entity.ErrForbidden)
+	}
+
+	return nil
+}
+
+// authorizeAuthors checks the request may give a news these authors. Only
+// editors may change who the authors are, writers can only keep them as is.
+func authorizeAuthors(c echo.Context, currentIDs []uint, authorIDs []uint) error {
+	if authorIDs == nil || common.Authorize(c, entity.PermNewsEditAny) == nil {
+		return nil
+	}
+
+	current := map[uint]bool{}
+	for _, id := range currentIDs {
+		current[id] = true
+	}
+
+	requested := map[uint]bool{}
+	for _, id := range authorIDs {
+		if !current[id] {
+			return fmt.Errorf("%w: only editors can change the authors of a news", entity.ErrForbidden)
+		}
+
+		requested[id] = true
+	}
+
+	if len(requested) != len(current) {
+		return fmt.Errorf("%w: only editors can change the authors of a news", entity.ErrForbidden)
+	}
+
+	return nil
+}
+
+// refreshTags recounts the news of tags after their news changed, tags only
+// count published news so status changes recount them too
+func (nc NewsController) refreshTags(c echo.Context, tags ...[]entity.Tag) {
+	tagIDs := []uint{}
+	seen := map[uint]bool{}
+
+	for _, list := range tags {
+		for _, tag := range list {
+			if !seen[tag.ID] {
+				seen[tag.ID] = true
+				tagIDs = append(tagIDs, tag.ID)
+			}
+		}
+	}
+
+	go services.DeleteCache(common.TenantID(c), tagEntity)
+	go services.RefreshTagPopularity(common.TenantID(c), nc.Repository, tagIDs)
+}
+
+// countView counts a view of a published news by the client of the request
+func countView(c echo.Context, newsID int, status string) {
+	if status == string(entity.StatusPublish) {
+		go services.CountView(common.TenantID(c), newsID, c.RealIP()+" "+c.Request().UserAgent())
+	}
+}
+
+// toNewsResponse builds the response of a news in a locale. A news not
+// translated to the locale is left in the default locale.
+func toNewsResponse(news entity.News, locale string) newsResponse {
+	tags := []tagResponse{}
+
+	for _, tag := range news.Tags {
+		tags = append(tags, tagResponse{ID: tag.ID, Name: tag.LocalizedName(locale), Slug: tag.Slug})
+	}
+
+	authors := []authorResponse{}
+
+	for _, author := range news.Authors {
+		authors = append(authors, authorResponse{ID: author.ID, Username: author.Username})
+	}
+
+	response := newsResponse{
+		ID:          int(news.ID),
+		Title:       news.Title,
+		Slug:        news.Slug,
+		Body:        news.Body,
+		BodyFormat:  string(news.BodyFormat),
+		BodyHTML:    news.BodyHTML,
+		Summary:     news.Excerpt,
+		WordCount:   news.WordCount,
+		ReadingTime: news.ReadingTime,
+		Status:      string(news.Status),
+		Tags:        tags,
+		Authors:     authors,
+		Version:     news.Version,
+		Views:       news.ViewCount,
+		PublishAt:   news.PublishAt,
+		UnpublishAt: news.UnpublishAt,
+		PublishedAt: news.PublishedAt,
+		CreatedAt:   news.CreatedAt,
+		UpdatedAt:   news.UpdatedAt,
+		SharedTags:  news.SharedTags,
+		Locale:      common.DefaultLocale(),
+	}
+
+	if translation, ok := news.Translation(locale); ok {
+		response.Title = translation.Title
+		response.Body = translation.Body
+		response.BodyHTML = translation.BodyHTML
+		response.Summary = translation.Excerpt
+		response.WordCount = translation.WordCount
+		response.ReadingTime = translation.ReadingTime
+		response.Locale = locale
+	}
+
+	if news.DeletedAt.Valid {
+		response.DeletedAt = &news.DeletedAt.Time
+	}
+
+	return response
+}
+
+func validateSchedule(publishAt, unpublishAt *time.Time) error {
+	if publishAt != nil && unpublishAt != nil && !unpublishAt.After(*publishAt) {
+		return entity.ValidationError{Fields: []entity.FieldError{{
+			Field:   "unpublish_at",
+			Message: "unpublish_at must be after publish_at",
+		}}}
+	}
+
+	return nil
+}
+
+func (nc NewsController) ReadTrash(c echo.Context) error {
+	projection, err := readProjection(c)
+	if err != nil {
+		return err
+	}
+
+	newsDB, err := nc.scoped(c).ReadTrash()
+	if err != nil {
+		return err
+	}
+
+	response := []newsResponse{}
+
+	for _, news := range newsDB {
+		response = append(response, toNewsResponse(news, common.DefaultLocale()))
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(projection.apply(response), "database"))
+}
+
+func (nc NewsController) Restore(c echo.Context) error {
+	newsID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	newsDB, err := nc.scoped(c).Restore(newsID, common.Actor(c))
+	if err != nil {
+		return err
+	}
+
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+
+	nc.refreshTags(c, newsDB.Tags)
 
 	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
 }
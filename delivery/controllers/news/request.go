@@ -1,13 +1,85 @@
 package news
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type CreateNewsRequest struct {
-	Title string `json:"title" validate:"required"`
-	Body  string `json:"body" validate:"required"`
-	Tags  []int  `json:"tags" validate:"required"`
+	Title       string     `json:"title" validate:"required"`
+	Body        string     `json:"body" validate:"required"`
+	BodyFormat  string     `json:"body_format" validate:"omitempty,oneof=plain markdown html"`
+	Summary     *string    `json:"summary" validate:"omitempty,max=1000"`
+	Tags        []int      `json:"tags" validate:"required_without=TagNames"`
+	TagNames    []string   `json:"tag_names" validate:"required_without=Tags"`
+	CreateTags  bool       `json:"create_tags"`
+	Authors     []uint     `json:"authors"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
 }
 
 type UpdateNewsRequest struct {
-	Title string `json:"title" validate:"omitempty"`
-	Body  string `json:"body" validate:"omitempty"`
-	Tags  []int  `json:"tags" validate:"required"`
+	Title       string       `json:"title" validate:"omitempty"`
+	Body        string       `json:"body" validate:"omitempty"`
+	BodyFormat  string       `json:"body_format" validate:"omitempty,oneof=plain markdown html"`
+	Summary     *string      `json:"summary" validate:"omitempty,max=1000"`
+	Tags        []int        `json:"tags" validate:"required_without=TagNames"`
+	TagNames    []string     `json:"tag_names" validate:"required_without=Tags"`
+	CreateTags  bool         `json:"create_tags"`
+	Authors     []uint       `json:"authors"`
+	PublishAt   OptionalTime `json:"publish_at,omitempty"`
+	UnpublishAt OptionalTime `json:"unpublish_at,omitempty"`
+	Version     uint         `json:"version"`
+}
+
+// OptionalTime is a time an update leaves out to keep it, or sends as null to
+// clear it. It keeps the JSON it was sent, so a time left out is empty.
+type OptionalTime []byte
+
+// NewOptionalTime returns the JSON of a time, or of null when it is nil
+func NewOptionalTime(t *time.Time) OptionalTime {
+	data, _ := json.Marshal(t)
+	return data
+}
+
+func (t *OptionalTime) UnmarshalJSON(data []byte) error {
+	var value *time.Time
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*t = append(OptionalTime{}, data...)
+
+	return nil
+}
+
+func (t OptionalTime) MarshalJSON() ([]byte, error) {
+	if len(t) == 0 {
+		return []byte("null"), nil
+	}
+
+	return t, nil
+}
+
+// Set reports whether the time was sent, null included
+func (t OptionalTime) Set() bool {
+	return len(t) > 0
+}
+
+// Time returns the time sent, or nil when it was null or left out
+func (t OptionalTime) Time() *time.Time {
+	var value *time.Time
+	_ = json.Unmarshal(t, &value)
+
+	return value
+}
+
+type StatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=draft review publish archived deleted"`
+}
+
+type TranslationRequest struct {
+	Title   string `json:"title" validate:"required"`
+	Body    string `json:"body" validate:"required"`
+	Summary string `json:"summary" validate:"max=1000"`
 }
@@ -1,9 +1,91 @@
 package news
 
+import (
+	"time"
+
+	"github.com/furqonzt99/news-redis/domain/entity"
+)
+
 type newsResponse struct {
-	ID     int      `json:"id"`
-	Title  string   `json:"title"`
-	Body   string   `json:"body"`
-	Status string   `json:"status"`
-	Tags   []string `json:"tags"`
+	ID          int              `json:"id"`
+	Title       string           `json:"title"`
+	Slug        string           `json:"slug"`
+	Body        string           `json:"body"`
+	BodyFormat  string           `json:"body_format"`
+	BodyHTML    string           `json:"body_html"`
+	Summary     string           `json:"summary"`
+	WordCount   int              `json:"word_count"`
+	ReadingTime int              `json:"reading_time"`
+	Locale      string           `json:"locale"`
+	Status      string           `json:"status"`
+	Tags        []tagResponse    `json:"tags"`
+	Authors     []authorResponse `json:"authors"`
+	Version     uint             `json:"version"`
+	Views       int64            `json:"views"`
+	PublishAt   *time.Time       `json:"publish_at,omitempty"`
+	UnpublishAt *time.Time       `json:"unpublish_at,omitempty"`
+	PublishedAt *time.Time       `json:"published_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	DeletedAt   *time.Time       `json:"deleted_at,omitempty"`
+	SharedTags  int64            `json:"shared_tags,omitempty"`
+}
+
+type tagResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type authorResponse struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+type translationResponse struct {
+	Locale      string    `json:"locale"`
+	Title       string    `json:"title"`
+	Body        string    `json:"body"`
+	BodyHTML    string    `json:"body_html"`
+	Summary     string    `json:"summary"`
+	WordCount   int       `json:"word_count"`
+	ReadingTime int       `json:"reading_time"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type trendingResponse struct {
+	newsResponse
+	WindowViews int64 `json:"window_views"`
+}
+
+type statusHistoryResponse struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type revisionResponse struct {
+	Number    int                  `json:"number"`
+	Action    string               `json:"action"`
+	Editor    string               `json:"editor"`
+	Title     string               `json:"title"`
+	Body      string               `json:"body,omitempty"`
+	Status    string               `json:"status"`
+	Tags      []entity.RevisionTag `json:"tags"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+type revisionDiff struct {
+	From        int                  `json:"from"`
+	To          int                  `json:"to"`
+	Title       *fieldChange         `json:"title,omitempty"`
+	Status      *fieldChange         `json:"status,omitempty"`
+	Body        []string             `json:"body,omitempty"`
+	TagsAdded   []entity.RevisionTag `json:"tags_added"`
+	TagsRemoved []entity.RevisionTag `json:"tags_removed"`
+}
+
+type fieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
 }
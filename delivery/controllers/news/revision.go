@@ -0,0 +1,180 @@
+package news
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/services"
+	"github.com/furqonzt99/news-redis/utils"
+	"github.com/labstack/echo/v4"
+)
+
+func (nc NewsController) ReadRevisions(c echo.Context) error {
+	newsID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	revisionsDB, err := nc.scoped(c).ReadRevisions(newsID)
+	if err != nil {
+		return err
+	}
+
+	response := []revisionResponse{}
+
+	for _, revision := range revisionsDB {
+		response = append(response, revisionResponse{
+			Number:    revision.Number,
+			Action:    revision.Action,
+			Editor:    revision.Editor,
+			Title:     revision.Title,
+			Status:    string(revision.Status),
+			Tags:      revision.RevisionTags(),
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
+}
+
+func (nc NewsController) ReadRevision(c echo.Context) error {
+	newsID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	number, err := common.ParamID(c, "revision")
+	if err != nil {
+		return err
+	}
+
+	revision, err := nc.scoped(c).ReadRevision(newsID, number)
+	if err != nil {
+		return err
+	}
+
+	response := revisionResponse{
+		Number:    revision.Number,
+		Action:    revision.Action,
+		Editor:    revision.Editor,
+		Title:     revision.Title,
+		Body:      revision.Body,
+		Status:    string(revision.Status),
+		Tags:      revision.RevisionTags(),
+		CreatedAt: revision.CreatedAt,
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
+}
+
+func (nc NewsController) DiffRevisions(c echo.Context) error {
+	newsID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	fields := []entity.FieldError{}
+
+	from, err := strconv.Atoi(c.QueryParam("from"))
+	if err != nil {
+		fields = append(fields, entity.FieldError{Field: "from", Message: "from must be a revision number"})
+	}
+
+	to, err := strconv.Atoi(c.QueryParam("to"))
+	if err != nil {
+		fields = append(fields, entity.FieldError{Field: "to", Message: "to must be a revision number"})
+	}
+
+	if len(fields) > 0 {
+		return entity.ValidationError{Fields: fields}
+	}
+
+	fromRevision, err := nc.scoped(c).ReadRevision(newsID, from)
+	if err != nil {
+		return err
+	}
+
+	toRevision, err := nc.scoped(c).ReadRevision(newsID, to)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(diffRevisions(fromRevision, toRevision), "database"))
+}
+
+func (nc NewsController) RestoreRevision(c echo.Context) error {
+	newsID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	number, err := common.ParamID(c, "revision")
+	if err != nil {
+		return err
+	}
+
+	oldNews, err := nc.scoped(c).ReadOne(newsID)
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeNews(c, oldNews, entity.PermNewsWrite); err != nil {
+		return err
+	}
+
+	newsDB, err := nc.scoped(c).RestoreRevision(newsID, number, common.Actor(c))
+	if err != nil {
+		return err
+	}
+
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+
+	nc.refreshTags(c, oldNews.Tags, newsDB.Tags)
+
+	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+}
+
+func diffRevisions(from, to entity.NewsRevision) revisionDiff {
+	diff := revisionDiff{
+		From:        from.Number,
+		To:          to.Number,
+		TagsAdded:   []entity.RevisionTag{},
+		TagsRemoved: []entity.RevisionTag{},
+	}
+
+	if from.Title != to.Title {
+		diff.Title = &fieldChange{From: from.Title, To: to.Title}
+	}
+
+	if from.Status != to.Status {
+		diff.Status = &fieldChange{From: string(from.Status), To: string(to.Status)}
+	}
+
+	if from.Body != to.Body {
+		diff.Body = utils.DiffLines(from.Body, to.Body)
+	}
+
+	fromTags := map[uint]bool{}
+	for _, tag := range from.RevisionTags() {
+		fromTags[tag.ID] = true
+	}
+
+	toTags := map[uint]bool{}
+	for _, tag := range to.RevisionTags() {
+		toTags[tag.ID] = true
+
+		if !fromTags[tag.ID] {
+			diff.TagsAdded = append(diff.TagsAdded, tag)
+		}
+	}
+
+	for _, tag := range from.RevisionTags() {
+		if !toTags[tag.ID] {
+			diff.TagsRemoved = append(diff.TagsRemoved, tag)
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,85 @@
+package news
+
+import (
+	"net/http"
+
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/services"
+	"github.com/labstack/echo/v4"
+)
+
+func (nc NewsController) ReadTranslations(c echo.Context) error {
+	newsID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	translationsDB, err := nc.scoped(c).ReadTranslations(newsID)
+	if err != nil {
+		return err
+	}
+
+	response := []translationResponse{}
+
+	for _, translation := range translationsDB {
+		response = append(response, translationResponse{
+			Locale:      translation.Locale,
+			Title:       translation.Title,
+			Body:        translation.Body,
+			BodyHTML:    translation.BodyHTML,
+			Summary:     translation.Excerpt,
+			WordCount:   translation.WordCount,
+			ReadingTime: translation.ReadingTime,
+			UpdatedAt:   translation.UpdatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
+}
+
+// SaveTranslation adds or replaces the translation of a news to a locale
+func (nc NewsController) SaveTranslation(c echo.Context) error {
+	newsID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	locale, err := common.TranslationLocale(c.Param("locale"))
+	if err != nil {
+		return err
+	}
+
+	var translationRequest TranslationRequest
+
+	if err := c.Bind(&translationRequest); err != nil {
+		return err
+	}
+
+	if err := c.Validate(&translationRequest); err != nil {
+		return err
+	}
+
+	news, err := nc.scoped(c).ReadOne(newsID)
+	if err != nil {
+		return err
+	}
+
+	if err := authorizeNews(c, news, entity.PermNewsWrite); err != nil {
+		return err
+	}
+
+	_, err = nc.scoped(c).SaveTranslation(newsID, entity.NewsTranslation{
+		Locale:  locale,
+		Title:   translationRequest.Title,
+		Body:    translationRequest.Body,
+		Summary: translationRequest.Summary,
+	})
+	if err != nil {
+		return err
+	}
+
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+
+	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+}
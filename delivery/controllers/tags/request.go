@@ -1,5 +1,16 @@
 package tags
 
 type TagRequest struct {
+	Name     string `json:"name" validate:"required"`
+	ParentID *uint  `json:"parent_id"`
+	Version  uint   `json:"version"`
+}
+
+type MergeRequest struct {
+	SourceIDs []int  `json:"source_ids" validate:"required,min=1"`
+	Name      string `json:"name"`
+}
+
+type TranslationRequest struct {
 	Name string `json:"name" validate:"required"`
 }
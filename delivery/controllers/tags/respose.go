@@ -1,6 +1,21 @@
 package tags
 
+import "time"
+
 type TagResponse struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Slug      string     `json:"slug"`
+	ParentID  *uint      `json:"parent_id"`
+	Version   uint       `json:"version"`
+	Count     int64      `json:"count"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type TagTreeResponse struct {
+	ID       int               `json:"id"`
+	Name     string            `json:"name"`
+	Slug     string            `json:"slug"`
+	Count    int64             `json:"count"`
+	Children []TagTreeResponse `json:"children"`
 }
@@ -3,7 +3,6 @@ package tags
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
 
 	"github.com/furqonzt99/news-redis/delivery/common"
 	"github.com/furqonzt99/news-redis/domain/entity"
@@ -13,6 +12,7 @@ import (
 )
 
 var tagEntity string = "tag"
+var newsEntity string = "news"
 
 type TagController struct {
 	Repository repository.TagInterface
@@ -22,50 +22,83 @@ func NewTagController(tagRepository repository.TagInterface) *TagController {
 	return &TagController{Repository: tagRepository}
 }
 
+// scoped returns the repository of the tenant of the request
+func (tc TagController) scoped(c echo.Context) repository.TagInterface {
+	return tc.Repository.ForTenant(common.TenantID(c))
+}
+
 func (tc TagController) Create(c echo.Context) error {
 	var tagRequest TagRequest
 
-	c.Bind(&tagRequest)
+	if err := c.Bind(&tagRequest); err != nil {
+		return err
+	}
 
 	if err := c.Validate(&tagRequest); err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
 	}
 
 	tag := entity.Tag{
-		Name: tagRequest.Name,
+		Name:     tagRequest.Name,
+		ParentID: tagRequest.ParentID,
 	}
 
-	_, err := tc.Repository.Create(tag)
+	_, err := tc.scoped(c).Create(tag)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
 	}
 
-	go services.DeleteCache(tagEntity)
+	go services.DeleteCache(common.TenantID(c), tagEntity)
 
 	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
 }
 
 func (tc TagController) ReadAll(c echo.Context) error {
 
+	status := entity.NewsStatus(c.QueryParam("status"))
+
+	if status != "" && !status.Valid() {
+		return entity.ValidationError{Fields: []entity.FieldError{{
+			Field:   "status",
+			Message: "status " + string(status) + " does not exist",
+		}}}
+	}
+
+	status, err := countedStatus(c, status)
+	if err != nil {
+		return err
+	}
+
+	locale, err := common.Locale(c)
+	if err != nil {
+		return err
+	}
+
+	cacheFilter := string(status) + ":" + locale
+
 	response := []TagResponse{}
 
 	// get data from cache
-	newsCache, err := services.GetCache(tagEntity, 0, "")
+	newsCache, err := services.GetCache(common.TenantID(c), tagEntity, 0, cacheFilter)
 	if err == nil {
 		// Unmarshal response
 		_ = json.Unmarshal([]byte(newsCache), &response)
 		return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "cache"))
 	}
 
-	tagsDB, err := tc.Repository.ReadAll()
+	tagsDB, err := tc.scoped(c).ReadAll(status)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
 	}
 
 	for _, tag := range tagsDB {
 		response = append(response, TagResponse{
-			ID:   int(tag.ID),
-			Name: tag.Name,
+			ID:       int(tag.ID),
+			Name:     tag.LocalizedName(locale),
+			Slug:     tag.Slug,
+			ParentID: tag.ParentID,
+			Version:  tag.Version,
+			Count:    tag.NewsCount,
 		})
 	}
 
@@ -73,51 +106,333 @@ func (tc TagController) ReadAll(c echo.Context) error {
 	resMarshal, _ := json.Marshal(response)
 
 	// Create cache
-	go services.CreateCache(tagEntity, 0, "", resMarshal)
+	go services.CreateCache(common.TenantID(c), tagEntity, 0, cacheFilter, resMarshal)
 
 	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
 }
 
 func (tc TagController) Edit(c echo.Context) error {
-	tagID, err := strconv.Atoi(c.Param("id"))
+	tagID, err := common.ParamID(c, "id")
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
 	}
 
 	var tagRequest TagRequest
 
-	c.Bind(&tagRequest)
+	if err := c.Bind(&tagRequest); err != nil {
+		return err
+	}
 
 	if err := c.Validate(&tagRequest); err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
 	}
 
 	tag := entity.Tag{
-		Name: tagRequest.Name,
+		Name:     tagRequest.Name,
+		ParentID: tagRequest.ParentID,
 	}
 
-	_, err = tc.Repository.Edit(tagID, tag)
+	tag.Version, err = common.ExpectedVersion(c, tagRequest.Version)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		return err
 	}
 
-	go services.DeleteCache(tagEntity)
+	_, err = tc.scoped(c).Edit(tagID, tag)
+	if err != nil {
+		return err
+	}
+
+	go services.DeleteCache(common.TenantID(c), tagEntity)
+	go services.DeleteCache(common.TenantID(c), newsEntity)
 
 	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
 }
 
 func (tc TagController) Delete(c echo.Context) error {
-	tagID, err := strconv.Atoi(c.Param("id"))
+	tagID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if c.QueryParam("hard") == "true" {
+		_, err = tc.scoped(c).Purge(tagID)
+	} else {
+		_, err = tc.scoped(c).Delete(tagID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	go services.DeleteCache(common.TenantID(c), tagEntity)
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+	go services.RemoveTagPopularity(common.TenantID(c), []uint{uint(tagID)})
+
+	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+}
+
+func (tc TagController) ReadTrash(c echo.Context) error {
+	tagsDB, err := tc.scoped(c).ReadTrash()
+	if err != nil {
+		return err
+	}
+
+	response := []TagResponse{}
+
+	for _, tag := range tagsDB {
+		response = append(response, TagResponse{
+			ID:        int(tag.ID),
+			Name:      tag.Name,
+			Slug:      tag.Slug,
+			Version:   tag.Version,
+			DeletedAt: &tag.DeletedAt.Time,
+		})
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
+}
+
+func (tc TagController) Restore(c echo.Context) error {
+	tagID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	tag, err := tc.scoped(c).Restore(tagID)
+	if err != nil {
+		return err
+	}
+
+	go services.DeleteCache(common.TenantID(c), tagEntity)
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+	go services.SetTagPopularity(common.TenantID(c), map[uint]int64{tag.ID: tag.NewsCount})
+
+	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+}
+
+func (tc TagController) Merge(c echo.Context) error {
+	tagID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var mergeRequest MergeRequest
+
+	if err := c.Bind(&mergeRequest); err != nil {
+		return err
+	}
+
+	if err := c.Validate(&mergeRequest); err != nil {
+		return err
+	}
+
+	tag, err := tc.scoped(c).Merge(tagID, mergeRequest.SourceIDs, mergeRequest.Name)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, common.NewBadRequestResponse())
+		return err
+	}
+
+	sources := []uint{}
+	for _, id := range mergeRequest.SourceIDs {
+		sources = append(sources, uint(id))
+	}
+
+	go services.DeleteCache(common.TenantID(c), tagEntity)
+	go services.DeleteCache(common.TenantID(c), newsEntity)
+	go services.RemoveTagPopularity(common.TenantID(c), sources)
+	go services.SetTagPopularity(common.TenantID(c), map[uint]int64{tag.ID: tag.NewsCount})
+
+	response := TagResponse{
+		ID:      int(tag.ID),
+		Name:    tag.Name,
+		Slug:    tag.Slug,
+		Version: tag.Version,
+		Count:   tag.NewsCount,
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
+}
+
+// SaveTranslation adds or replaces the name of a tag in a locale
+func (tc TagController) SaveTranslation(c echo.Context) error {
+	tagID, err := common.ParamID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	locale, err := common.TranslationLocale(c.Param("locale"))
+	if err != nil {
+		return err
+	}
+
+	var translationRequest TranslationRequest
+
+	if err := c.Bind(&translationRequest); err != nil {
+		return err
 	}
 
-	_, err = tc.Repository.Delete(tagID)
+	if err := c.Validate(&translationRequest); err != nil {
+		return err
+	}
+
+	_, err = tc.scoped(c).SaveTranslation(tagID, entity.TagTranslation{
+		Locale: locale,
+		Name:   translationRequest.Name,
+	})
 	if err != nil {
-		return c.JSON(http.StatusNotFound, common.NewNotFoundResponse())
+		return err
 	}
 
-	go services.DeleteCache(tagEntity)
+	go services.DeleteCache(common.TenantID(c), tagEntity)
+	go services.DeleteCache(common.TenantID(c), newsEntity)
 
 	return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
 }
+
+func (tc TagController) ReadPopular(c echo.Context) error {
+	limit, err := common.QueryLimit(c, 10)
+	if err != nil {
+		return err
+	}
+
+	locale, err := common.Locale(c)
+	if err != nil {
+		return err
+	}
+
+	popularity, err := services.ReadPopularTags(common.TenantID(c), int64(limit))
+	if err != nil {
+		return err
+	}
+
+	tagIDs := []uint{}
+	for _, popular := range popularity {
+		tagIDs = append(tagIDs, popular.TagID)
+	}
+
+	response := []TagResponse{}
+
+	if len(tagIDs) == 0 {
+		return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "cache"))
+	}
+
+	tagsDB, err := tc.scoped(c).ReadByIDs(tagIDs)
+	if err != nil {
+		return err
+	}
+
+	tagsByID := map[uint]entity.Tag{}
+	for _, tag := range tagsDB {
+		tagsByID[tag.ID] = tag
+	}
+
+	for _, popular := range popularity {
+		tag, ok := tagsByID[popular.TagID]
+		if !ok {
+			continue
+		}
+
+		response = append(response, TagResponse{
+			ID:      int(tag.ID),
+			Name:    tag.LocalizedName(locale),
+			Slug:    tag.Slug,
+			Version: tag.Version,
+			Count:   popular.Count,
+		})
+	}
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "cache"))
+}
+
+func (tc TagController) ReadTree(c echo.Context) error {
+
+	status, err := countedStatus(c, "")
+	if err != nil {
+		return err
+	}
+
+	locale, err := common.Locale(c)
+	if err != nil {
+		return err
+	}
+
+	cacheFilter := "tree:" + string(status) + ":" + locale
+
+	response := []TagTreeResponse{}
+
+	// get data from cache
+	tagCache, err := services.GetCache(common.TenantID(c), tagEntity, 0, cacheFilter)
+	if err == nil {
+		// Unmarshal response
+		_ = json.Unmarshal([]byte(tagCache), &response)
+		return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "cache"))
+	}
+
+	tagsDB, err := tc.scoped(c).ReadAll(status)
+	if err != nil {
+		return err
+	}
+
+	response = buildTagTree(tagsDB, locale)
+
+	// Marshal response
+	resMarshal, _ := json.Marshal(response)
+
+	// Create cache
+	go services.CreateCache(common.TenantID(c), tagEntity, 0, cacheFilter, resMarshal)
+
+	return c.JSON(http.StatusOK, common.SuccessResponseWithData(response, "database"))
+}
+
+// countedStatus returns the status of the news tags are counted by. Anonymous
+// readers only count published news and may not ask for another status.
+func countedStatus(c echo.Context, status entity.NewsStatus) (entity.NewsStatus, error) {
+	if common.Authorize(c, entity.PermNewsReadAny) == nil {
+		return status, nil
+	}
+
+	if status != "" && status != entity.StatusPublish {
+		return "", common.Authorize(c, entity.PermNewsReadAny)
+	}
+
+	return entity.StatusPublish, nil
+}
+
+// buildTagTree nests tags under their parent. Tags whose parent is trashed
+// are shown as roots.
+func buildTagTree(tags []entity.Tag, locale string) []TagTreeResponse {
+	exists := map[uint]bool{}
+	for _, tag := range tags {
+		exists[tag.ID] = true
+	}
+
+	roots := []entity.Tag{}
+	children := map[uint][]entity.Tag{}
+
+	for _, tag := range tags {
+		if tag.ParentID == nil || !exists[*tag.ParentID] {
+			roots = append(roots, tag)
+		} else {
+			children[*tag.ParentID] = append(children[*tag.ParentID], tag)
+		}
+	}
+
+	var build func(tags []entity.Tag) []TagTreeResponse
+
+	build = func(tags []entity.Tag) []TagTreeResponse {
+		tree := []TagTreeResponse{}
+
+		for _, tag := range tags {
+			tree = append(tree, TagTreeResponse{
+				ID:       int(tag.ID),
+				Name:     tag.LocalizedName(locale),
+				Slug:     tag.Slug,
+				Count:    tag.NewsCount,
+				Children: build(children[tag.ID]),
+			})
+		}
+
+		return tree
+	}
+
+	return build(roots)
+}
@@ -0,0 +1,137 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/furqonzt99/news-redis/services"
+	"github.com/labstack/echo/v4"
+)
+
+// AuthMiddleware only lets through requests authenticated either with a JWT
+// access token or with an API key
+func AuthMiddleware(apiKeys repository.APIKeyInterface) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if key, ok := common.APIKey(c); ok {
+				if err := authenticateAPIKey(c, apiKeys, key); err != nil {
+					return err
+				}
+
+				return next(c)
+			}
+
+			if err := authenticateJWT(c); err != nil {
+				return err
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// OptionalAuthMiddleware authenticates the requests sending credentials, like
+// AuthMiddleware, and lets the others through anonymously
+func OptionalAuthMiddleware(apiKeys repository.APIKeyInterface) echo.MiddlewareFunc {
+	auth := AuthMiddleware(apiKeys)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		authenticated := auth(next)
+
+		return func(c echo.Context) error {
+			if c.Request().Header.Get(echo.HeaderAuthorization) == "" {
+				return next(c)
+			}
+
+			return authenticated(c)
+		}
+	}
+}
+
+// JWTMiddleware only lets through requests with a valid, not revoked, access
+// token in the `Authorization: Bearer ...` header
+func JWTMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := authenticateJWT(c); err != nil {
+				return err
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// PermissionMiddleware only lets through authenticated requests whose role
+// has the permission, the others get a 403
+func PermissionMiddleware(permission entity.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := common.Authorize(c, permission); err != nil {
+				return err
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func authenticateJWT(c echo.Context) error {
+	token, ok := common.BearerToken(c)
+	if !ok {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+		return fmt.Errorf("%w: missing bearer token", entity.ErrUnauthorized)
+	}
+
+	claims, err := services.ParseToken(token, services.AccessToken)
+	if err != nil {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+		return err
+	}
+
+	identity := common.Identity{
+		TenantID: claims.Tenant(),
+		UserID:   claims.UserID(),
+		Username: claims.Username,
+		Role:     claims.Role,
+	}
+
+	if err := authorizeTenant(c, identity); err != nil {
+		return err
+	}
+
+	common.SetIdentity(c, identity)
+
+	return nil
+}
+
+func authenticateAPIKey(c echo.Context, apiKeys repository.APIKeyInterface, key string) error {
+	apiKey, err := apiKeys.ReadByHash(services.HashAPIKey(key))
+	if errors.Is(err, entity.ErrNotFound) || (err == nil && !apiKey.Active(time.Now())) {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, "ApiKey")
+		return fmt.Errorf("%w: invalid api key", entity.ErrUnauthorized)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	identity := common.Identity{
+		TenantID: apiKey.TenantID,
+		Username: "apikey:" + apiKey.Name,
+		APIKeyID: apiKey.ID,
+		Scopes:   apiKey.ScopeList(),
+	}
+
+	if err := authorizeTenant(c, identity); err != nil {
+		return err
+	}
+
+	common.SetIdentity(c, identity)
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/furqonzt99/news-redis/constants"
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/go-redis/redis/v8"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+// rateLimitPrefix must not start with the cache prefix, or DeleteCache would remove the windows
+const rateLimitPrefix = "ratelimit:"
+
+// RateLimitRule limits the requests each client makes to a group of routes
+// within a sliding window. A rule without Match applies to every request.
+type RateLimitRule struct {
+	Group  string
+	Limit  int
+	Window time.Duration
+	Match  func(c echo.Context) bool
+}
+
+// ReadRequest matches the requests that do not change anything
+func ReadRequest(c echo.Context) bool {
+	method := c.Request().Method
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// WriteRequest matches the requests that may change something
+func WriteRequest(c echo.Context) bool {
+	return !ReadRequest(c)
+}
+
+// KeyByIP identifies clients by their IP address
+func KeyByIP(c echo.Context) string {
+	return "ip:" + c.RealIP()
+}
+
+// KeyByAPIKey identifies clients by the API key they authenticated with, or
+// else by their IP address. The rate limit must come after the auth
+// middleware, keys which were not authenticated count against their IP.
+func KeyByAPIKey(c echo.Context) string {
+	if identity, ok := common.CurrentIdentity(c); ok && identity.APIKeyID != 0 {
+		return fmt.Sprint("key:", identity.APIKeyID)
+	}
+
+	return KeyByIP(c)
+}
+
+// ClientKey returns the key function named by the RATE_LIMIT_KEY setting
+func ClientKey(name string) func(c echo.Context) string {
+	if name == "api_key" {
+		return KeyByAPIKey
+	}
+
+	return KeyByIP
+}
+
+// RateLimitMiddleware rejects with 429 the requests of a client going over
+// the limit of a rule. Windows are kept in Redis so they are shared by every
+// API instance, and in memory while Redis is unavailable.
+func RateLimitMiddleware(keyFunc func(c echo.Context) string, rules ...RateLimitRule) echo.MiddlewareFunc {
+	limiter := &rateLimiter{memory: map[string][]time.Time{}}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for _, rule := range rules {
+				if rule.Limit <= 0 || (rule.Match != nil && !rule.Match(c)) {
+					continue
+				}
+
+				result := limiter.allow(rateLimitPrefix+rule.Group+":"+keyFunc(c), rule, time.Now())
+
+				header := c.Response().Header()
+				header.Set("RateLimit-Limit", strconv.Itoa(rule.Limit))
+				header.Set("RateLimit-Remaining", strconv.Itoa(result.remaining))
+				header.Set("RateLimit-Reset", strconv.Itoa(result.resetSeconds()))
+
+				if !result.allowed {
+					header.Set(echo.HeaderRetryAfter, strconv.Itoa(result.resetSeconds()))
+					return echo.NewHTTPError(http.StatusTooManyRequests, "too many requests")
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
+
+type rateLimitResult struct {
+	allowed   bool
+	remaining int
+	reset     time.Duration
+}
+
+func (r rateLimitResult) resetSeconds() int {
+	return int(math.Ceil(r.reset.Seconds()))
+}
+
+type rateLimiter struct {
+	mu        sync.Mutex
+	memory    map[string][]time.Time
+	maxWindow time.Duration
+	lastSweep time.Time
+	// redisDown is set while Redis fails, so the fallback is logged once
+	redisDown int32
+}
+
+// slidingWindow keeps the times of the requests of a client in a sorted set,
+// dropping those older than the window. It returns whether the request is
+// allowed, the requests left and the milliseconds until the oldest one expires.
+var slidingWindow = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+
+local count = redis.call("ZCARD", KEYS[1])
+local allowed = 0
+
+if count < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+
+redis.call("PEXPIRE", KEYS[1], window)
+
+local reset = window
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+return {allowed, limit - count, reset}
+`)
+
+func (l *rateLimiter) allow(key string, rule RateLimitRule, now time.Time) rateLimitResult {
+	if constants.Rdb != nil {
+		result, err := l.allowRedis(key, rule, now)
+		if err == nil {
+			if atomic.CompareAndSwapInt32(&l.redisDown, 1, 0) {
+				log.Info("rate limit: redis is back, using it again")
+			}
+
+			return result
+		}
+
+		if atomic.CompareAndSwapInt32(&l.redisDown, 0, 1) {
+			log.Warnf("rate limit: redis unavailable, limiting in memory: %v", err)
+		}
+	}
+
+	return l.allowMemory(key, rule, now)
+}
+
+func (l *rateLimiter) allowRedis(key string, rule RateLimitRule, now time.Time) (rateLimitResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), rand.Int63())
+
+	values, err := slidingWindow.Run(ctx, constants.Rdb, []string{key},
+		now.UnixMilli(), rule.Window.Milliseconds(), rule.Limit, member).Int64Slice()
+	if err != nil {
+		return rateLimitResult{}, err
+	}
+
+	return rateLimitResult{
+		allowed:   values[0] == 1,
+		remaining: int(values[1]),
+		reset:     time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+func (l *rateLimiter) allowMemory(key string, rule RateLimitRule, now time.Time) rateLimitResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if rule.Window > l.maxWindow {
+		l.maxWindow = rule.Window
+	}
+
+	l.sweep(now)
+
+	requests := l.memory[key]
+
+	// drop the requests that left the window
+	start := 0
+	for start < len(requests) && !requests[start].After(now.Add(-rule.Window)) {
+		start++
+	}
+	requests = requests[start:]
+
+	allowed := len(requests) < rule.Limit
+	if allowed {
+		requests = append(requests, now)
+	}
+
+	if len(requests) == 0 {
+		delete(l.memory, key)
+	} else {
+		l.memory[key] = requests
+	}
+
+	reset := rule.Window
+	if len(requests) > 0 {
+		reset = requests[0].Add(rule.Window).Sub(now)
+	}
+
+	return rateLimitResult{
+		allowed:   allowed,
+		remaining: rule.Limit - len(requests),
+		reset:     reset,
+	}
+}
+
+// sweep forgets the clients that made no request within the longest window,
+// at most once per window
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.maxWindow {
+		return
+	}
+
+	l.lastSweep = now
+
+	for key, requests := range l.memory {
+		if !requests[len(requests)-1].After(now.Add(-l.maxWindow)) {
+			delete(l.memory, key)
+		}
+	}
+}
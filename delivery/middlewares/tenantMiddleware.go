@@ -0,0 +1,144 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// TenantHeader picks the tenant of a request by its slug
+const TenantHeader = "X-Tenant"
+
+const (
+	// tenantCacheTTL is how long resolved tenants are kept in memory
+	tenantCacheTTL = time.Minute
+	// maxCachedTenants bounds the cache, since any host may be sent
+	maxCachedTenants = 1000
+)
+
+// TenantMiddleware resolves the tenant of every request from the X-Tenant
+// header, or else from the host the request was sent to. Requests matching no
+// host go to the default tenant, an unknown X-Tenant, or a missing default
+// tenant, gets a 404.
+func TenantMiddleware(e *echo.Echo, tenants repository.TenantInterface) {
+	resolver := &tenantResolver{tenants: tenants, cache: map[string]cachedTenant{}}
+
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// shared caches must not serve a publication to another one
+			common.Vary(c, TenantHeader)
+
+			tenant, err := resolver.resolve(c)
+			if err != nil {
+				return err
+			}
+
+			common.SetTenant(c, tenant)
+
+			return next(c)
+		}
+	})
+}
+
+// authorizeTenant fails with ErrForbidden when a user or an API key is used
+// on another tenant than its own, identities only act within their tenant.
+// A request without a tenant matches no identity.
+func authorizeTenant(c echo.Context, identity common.Identity) error {
+	if identity.TenantID == common.TenantID(c) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s does not belong to this publication", entity.ErrForbidden, identity.Username)
+}
+
+type cachedTenant struct {
+	tenant  entity.Tenant
+	found   bool
+	expires time.Time
+}
+
+type tenantResolver struct {
+	tenants repository.TenantInterface
+	mu      sync.Mutex
+	cache   map[string]cachedTenant
+}
+
+func (r *tenantResolver) resolve(c echo.Context) (entity.Tenant, error) {
+	if slug := strings.TrimSpace(c.Request().Header.Get(TenantHeader)); slug != "" {
+		tenant, found, err := r.lookup("slug:"+strings.ToLower(slug), func() (entity.Tenant, error) {
+			return r.tenants.ReadBySlug(slug)
+		})
+		if err != nil {
+			return tenant, err
+		}
+
+		if !found {
+			return tenant, fmt.Errorf("%w: publication %q does not exist", entity.ErrNotFound, slug)
+		}
+
+		return tenant, nil
+	}
+
+	host := c.Request().Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	tenant, found, err := r.lookup("host:"+strings.ToLower(host), func() (entity.Tenant, error) {
+		return r.tenants.ReadByHost(host)
+	})
+	if err != nil || found {
+		return tenant, err
+	}
+
+	tenant, found, err = r.lookup("default", func() (entity.Tenant, error) {
+		return r.tenants.ReadOne(int(entity.DefaultTenantID))
+	})
+	if err != nil {
+		return tenant, err
+	}
+
+	if !found {
+		return tenant, fmt.Errorf("%w: no publication is served on %q", entity.ErrNotFound, host)
+	}
+
+	return tenant, nil
+}
+
+// lookup returns a tenant from the cache, or reads it and caches it, along
+// with whether it exists
+func (r *tenantResolver) lookup(key string, read func() (entity.Tenant, error)) (entity.Tenant, bool, error) {
+	now := time.Now()
+
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	r.mu.Unlock()
+
+	if ok && now.Before(cached.expires) {
+		return cached.tenant, cached.found, nil
+	}
+
+	tenant, err := read()
+	if err != nil && !errors.Is(err, entity.ErrNotFound) {
+		return tenant, false, err
+	}
+
+	cached = cachedTenant{tenant: tenant, found: err == nil, expires: now.Add(tenantCacheTTL)}
+
+	r.mu.Lock()
+	if len(r.cache) >= maxCachedTenants {
+		r.cache = map[string]cachedTenant{}
+	}
+	r.cache[key] = cached
+	r.mu.Unlock()
+
+	return cached.tenant, cached.found, nil
+}
@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/labstack/echo/v4"
+)
+
+// ReadVary are the request headers news and tag reads depend on: the locale,
+// who reads them, as anonymous readers only see published news, and the tenant
+var ReadVary = []string{"Accept-Language", echo.HeaderAuthorization, TenantHeader}
+
+// VaryMiddleware lists the request headers a response depends on in its Vary
+// header, so shared caches keep the responses to different ones apart
+func VaryMiddleware(headers ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			common.Vary(c, headers...)
+
+			return next(c)
+		}
+	}
+}
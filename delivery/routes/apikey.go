@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/furqonzt99/news-redis/delivery/controllers/apikeys"
+	"github.com/furqonzt99/news-redis/delivery/middlewares"
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterAPIKeyPath(e *echo.Echo, apiKeyController *apikeys.APIKeyController, auth, rateLimit echo.MiddlewareFunc) {
+	canManage := middlewares.PermissionMiddleware(entity.PermAPIKeysManage)
+
+	e.POST("/apikeys", apiKeyController.Create, auth, rateLimit, canManage)
+	e.GET("/apikeys", apiKeyController.ReadAll, auth, rateLimit, canManage)
+	e.DELETE("/apikeys/:id", apiKeyController.Revoke, auth, rateLimit, canManage)
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/furqonzt99/news-redis/delivery/controllers/auth"
+	"github.com/furqonzt99/news-redis/delivery/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterAuthPath(e *echo.Echo, authController *auth.AuthController, rateLimit echo.MiddlewareFunc) {
+	e.POST("/auth/login", authController.Login, rateLimit)
+	e.POST("/auth/refresh", authController.Refresh, rateLimit)
+	e.POST("/auth/logout", authController.Logout, middlewares.JWTMiddleware())
+}
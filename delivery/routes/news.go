@@ -2,16 +2,37 @@ package routes
 
 import (
 	"github.com/furqonzt99/news-redis/delivery/controllers/news"
+	"github.com/furqonzt99/news-redis/delivery/middlewares"
+	"github.com/furqonzt99/news-redis/domain/entity"
 	"github.com/labstack/echo/v4"
 )
 
-func RegisterNewsPath(e *echo.Echo, newsController *news.NewsController) {
-	e.POST("/news", newsController.Create)
-	e.GET("/news", newsController.ReadAll)
-	e.GET("/news/:id", newsController.ReadOne)
-	e.PUT("/news/:id", newsController.Edit)
-	e.PUT("/news/:id/publish", newsController.SetStatusPublish)
-	e.PUT("/news/:id/draft", newsController.SetStatusDraft)
-	e.PUT("/news/:id/deleted", newsController.SetStatusDeleted)
-	e.DELETE("/news/:id", newsController.Delete)
+func RegisterNewsPath(e *echo.Echo, newsController *news.NewsController, auth, optionalAuth, rateLimit echo.MiddlewareFunc) {
+	canRead := middlewares.PermissionMiddleware(entity.PermNewsReadAny)
+	canWrite := middlewares.PermissionMiddleware(entity.PermNewsWrite)
+	canPublish := middlewares.PermissionMiddleware(entity.PermNewsPublish)
+	canDelete := middlewares.PermissionMiddleware(entity.PermNewsDelete)
+	vary := middlewares.VaryMiddleware(middlewares.ReadVary...)
+
+	e.POST("/news", newsController.Create, auth, rateLimit, canWrite)
+	e.GET("/news", newsController.ReadAll, optionalAuth, rateLimit, vary)
+	e.GET("/news/trash", newsController.ReadTrash, auth, rateLimit, canDelete)
+	e.GET("/news/trending", newsController.ReadTrending, rateLimit, vary)
+	e.GET("/news/slug/:slug", newsController.ReadBySlug, optionalAuth, rateLimit, vary)
+	e.GET("/news/:id", newsController.ReadOne, optionalAuth, rateLimit, vary)
+	e.GET("/news/:id/related", newsController.ReadRelated, optionalAuth, rateLimit, vary)
+	e.PUT("/news/:id", newsController.Edit, auth, rateLimit, canWrite)
+	e.PUT("/news/:id/publish", newsController.SetStatusPublish, auth, rateLimit, canPublish)
+	e.PUT("/news/:id/draft", newsController.SetStatusDraft, auth, rateLimit, canWrite)
+	e.PUT("/news/:id/deleted", newsController.SetStatusDeleted, auth, rateLimit, canDelete)
+	e.PUT("/news/:id/status", newsController.SetStatus, auth, rateLimit, canWrite)
+	e.GET("/news/:id/status/history", newsController.ReadStatusHistory, auth, rateLimit, canRead)
+	e.GET("/news/:id/revisions", newsController.ReadRevisions, auth, rateLimit, canRead)
+	e.GET("/news/:id/revisions/diff", newsController.DiffRevisions, auth, rateLimit, canRead)
+	e.GET("/news/:id/revisions/:revision", newsController.ReadRevision, auth, rateLimit, canRead)
+	e.POST("/news/:id/revisions/:revision/restore", newsController.RestoreRevision, auth, rateLimit, canWrite)
+	e.GET("/news/:id/translations", newsController.ReadTranslations, auth, rateLimit, canRead)
+	e.PUT("/news/:id/translations/:locale", newsController.SaveTranslation, auth, rateLimit, canWrite)
+	e.POST("/news/:id/restore", newsController.Restore, auth, rateLimit, canDelete)
+	e.DELETE("/news/:id", newsController.Delete, auth, rateLimit, canDelete)
 }
@@ -2,12 +2,23 @@ package routes
 
 import (
 	"github.com/furqonzt99/news-redis/delivery/controllers/tags"
+	"github.com/furqonzt99/news-redis/delivery/middlewares"
+	"github.com/furqonzt99/news-redis/domain/entity"
 	"github.com/labstack/echo/v4"
 )
 
-func RegisterTagPath(e *echo.Echo, tagController *tags.TagController) {
-	e.POST("/tags", tagController.Create)
-	e.GET("/tags", tagController.ReadAll)
-	e.PUT("/tags/:id", tagController.Edit)
-	e.DELETE("/tags/:id", tagController.Delete)
+func RegisterTagPath(e *echo.Echo, tagController *tags.TagController, auth, optionalAuth, rateLimit echo.MiddlewareFunc) {
+	canManage := middlewares.PermissionMiddleware(entity.PermTagsManage)
+	vary := middlewares.VaryMiddleware(middlewares.ReadVary...)
+
+	e.POST("/tags", tagController.Create, auth, rateLimit, canManage)
+	e.GET("/tags", tagController.ReadAll, optionalAuth, rateLimit, vary)
+	e.GET("/tags/trash", tagController.ReadTrash, auth, rateLimit, canManage)
+	e.GET("/tags/popular", tagController.ReadPopular, optionalAuth, rateLimit, vary)
+	e.GET("/tags/tree", tagController.ReadTree, optionalAuth, rateLimit, vary)
+	e.PUT("/tags/:id", tagController.Edit, auth, rateLimit, canManage)
+	e.POST("/tags/:id/restore", tagController.Restore, auth, rateLimit, canManage)
+	e.POST("/tags/:id/merge", tagController.Merge, auth, rateLimit, canManage)
+	e.PUT("/tags/:id/translations/:locale", tagController.SaveTranslation, auth, rateLimit, canManage)
+	e.DELETE("/tags/:id", tagController.Delete, auth, rateLimit, canManage)
 }
@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Scope is what an API key may be used for
+type Scope string
+
+const (
+	ScopeNewsRead    Scope = "news:read"
+	ScopeNewsWrite   Scope = "news:write"
+	ScopeNewsPublish Scope = "news:publish"
+	ScopeNewsDelete  Scope = "news:delete"
+	ScopeTagsWrite   Scope = "tags:write"
+)
+
+var scopePermissions = map[Scope][]Permission{
+	ScopeNewsRead:    {PermNewsReadAny},
+	ScopeNewsWrite:   {PermNewsWrite, PermNewsEditAny},
+	ScopeNewsPublish: {PermNewsPublish},
+	ScopeNewsDelete:  {PermNewsDelete},
+	ScopeTagsWrite:   {PermTagsManage},
+}
+
+func (s Scope) Valid() bool {
+	_, ok := scopePermissions[s]
+	return ok
+}
+
+func (s Scope) Can(permission Permission) bool {
+	for _, granted := range scopePermissions[s] {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// APIKey lets machine clients call the API of its tenant without logging in.
+// Only the hash of the key is stored, the key itself is shown once when it is
+// created.
+type APIKey struct {
+	gorm.Model
+	TenantID  uint   `gorm:"not null;default:1;index"`
+	Name      string `gorm:"size:100"`
+	Prefix    string `gorm:"size:16"`
+	Hash      string `gorm:"size:64;uniqueIndex"`
+	Scopes    string
+	CreatedBy string `gorm:"size:100"`
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+}
+
+func (k *APIKey) SetScopes(scopes []Scope) {
+	names := []string{}
+	for _, scope := range scopes {
+		names = append(names, string(scope))
+	}
+
+	k.Scopes = strings.Join(names, ",")
+}
+
+func (k APIKey) ScopeList() []Scope {
+	scopes := []Scope{}
+
+	for _, name := range strings.Split(k.Scopes, ",") {
+		if name != "" {
+			scopes = append(scopes, Scope(name))
+		}
+	}
+
+	return scopes
+}
+
+// Active reports whether the key can still be used at the given time
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
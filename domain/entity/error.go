@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e ValidationError) Error() string {
+	messages := []string{}
+
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+
+	return "validation failed: " + strings.Join(messages, ", ")
+}
+
+type UnknownTagError struct {
+	IDs   []int
+	Names []string
+}
+
+func (e UnknownTagError) Error() string {
+	messages := []string{}
+
+	if len(e.IDs) > 0 {
+		messages = append(messages, "unknown tag ids: "+strings.Trim(fmt.Sprint(e.IDs), "[]"))
+	}
+
+	if len(e.Names) > 0 {
+		messages = append(messages, "unknown tag names: "+strings.Join(e.Names, ", "))
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// VersionConflictError is returned when a write was based on a stale version
+type VersionConflictError struct {
+	Current uint
+}
+
+func (e VersionConflictError) Error() string {
+	return fmt.Sprintf("%v: version is stale, current version is %d", ErrConflict, e.Current)
+}
+
+func (e VersionConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// CheckVersion fails with a VersionConflictError when expected is not current
+func CheckVersion(current, expected uint) error {
+	if current != expected {
+		return VersionConflictError{Current: current}
+	}
+
+	return nil
+}
@@ -2,26 +2,76 @@ package entity
 
 import (
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// MaxNewsSlugLength is the length news slugs are cut to, leaving room in the
+// slug column for a numeric suffix
+const MaxNewsSlugLength = 200
+
 type News struct {
 	gorm.Model
-	Title  string
-	Body   string
-	Status string `gorm:"default:draft"`
-	Tags   []Tag  `gorm:"many2many:news_tags;"`
+	TenantID     uint `gorm:"not null;default:1;uniqueIndex:idx_news_tenant_slug,priority:1"`
+	Title        string
+	Slug         string `gorm:"size:255;uniqueIndex:idx_news_tenant_slug,priority:2"`
+	Body         string
+	BodyFormat   BodyFormat `gorm:"size:20;not null;default:plain"`
+	BodyHTML     string
+	Summary      *string
+	Excerpt      string
+	WordCount    int        `gorm:"not null;default:0"`
+	ReadingTime  int        `gorm:"not null;default:0"`
+	Status       NewsStatus `gorm:"size:20;default:draft"`
+	PublishAt    *time.Time `gorm:"index"`
+	UnpublishAt  *time.Time `gorm:"index"`
+	PublishedAt  *time.Time
+	EditedBy     string `gorm:"size:100"`
+	Version      uint   `gorm:"not null;default:1"`
+	ViewCount    int64  `gorm:"not null;default:0"`
+	Tags         []Tag  `gorm:"many2many:news_tags;"`
+	Authors      []User `gorm:"many2many:news_authors;"`
+	Translations []NewsTranslation
+	SharedTags   int64 `gorm:"->;-:migration"`
+}
+
+// NewsSlug is a former slug of a news, kept so old URLs keep working
+type NewsSlug struct {
+	ID        uint   `gorm:"primaryKey"`
+	TenantID  uint   `gorm:"not null;default:1;uniqueIndex:idx_news_slugs_tenant_slug,priority:1"`
+	NewsID    uint   `gorm:"index"`
+	Slug      string `gorm:"size:255;uniqueIndex:idx_news_slugs_tenant_slug,priority:2"`
+	CreatedAt time.Time
 }
 
 type NewsFilter struct {
-	Status string
-	Tags   []string
+	Status          NewsStatus
+	Tags            []string
+	IncludeChildren bool
+	Author          string
+}
+
+// TagSelection holds the tags chosen for a news, by id and/or by name
+type TagSelection struct {
+	IDs           []int
+	Names         []string
+	CreateMissing bool
+}
+
+// ScheduleChange holds the schedule times an edit sets. The times which are
+// not set are kept, and one set to nil clears that schedule.
+type ScheduleChange struct {
+	PublishAt      *time.Time
+	UnpublishAt    *time.Time
+	SetPublishAt   bool
+	SetUnpublishAt bool
 }
 
 type NewsTags struct {
-	NewsID uint `gorm:"primaryKey"`
-	TagID  uint `gorm:"primaryKey"`
+	NewsID   uint `gorm:"primaryKey"`
+	TagID    uint `gorm:"primaryKey"`
+	TenantID uint `gorm:"not null;default:1;index"`
 }
 
 func (NewsTags) BeforeCreate(db *gorm.DB) error {
@@ -33,3 +83,42 @@ func (NewsTags) BeforeCreate(db *gorm.DB) error {
 
 	return nil
 }
+
+type NewsAuthors struct {
+	NewsID uint `gorm:"primaryKey"`
+	UserID uint `gorm:"primaryKey"`
+}
+
+func (NewsAuthors) BeforeCreate(db *gorm.DB) error {
+	err := db.SetupJoinTable(&News{}, "Authors", &NewsAuthors{})
+
+	if err != nil {
+		return errors.New(err.Error())
+	}
+
+	return nil
+}
+
+// IsAuthor reports whether a user is one of the authors of the news
+func (n News) IsAuthor(userID uint) bool {
+	for _, author := range n.Authors {
+		if author.ID == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BodyFormat is the markup a news body is written in
+type BodyFormat string
+
+const (
+	FormatPlain    BodyFormat = "plain"
+	FormatMarkdown BodyFormat = "markdown"
+	FormatHTML     BodyFormat = "html"
+)
+
+func (f BodyFormat) Valid() bool {
+	return f == FormatPlain || f == FormatMarkdown || f == FormatHTML
+}
@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	RevisionCreate   = "create"
+	RevisionEdit     = "edit"
+	RevisionStatus   = "status"
+	RevisionRestore  = "restore"
+	RevisionUndelete = "undelete"
+)
+
+// NewsRevision is a snapshot of a news taken after every change
+type NewsRevision struct {
+	ID         uint `gorm:"primaryKey"`
+	NewsID     uint `gorm:"uniqueIndex:idx_news_revision"`
+	Number     int  `gorm:"uniqueIndex:idx_news_revision"`
+	Action     string
+	Editor     string
+	Title      string
+	Body       string
+	BodyFormat BodyFormat `gorm:"size:20"`
+	Status     NewsStatus `gorm:"size:20"`
+	Tags       string
+	CreatedAt  time.Time
+}
+
+type RevisionTag struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+func (r *NewsRevision) SetTags(tags []Tag) {
+	revisionTags := []RevisionTag{}
+
+	for _, tag := range tags {
+		revisionTags = append(revisionTags, RevisionTag{ID: tag.ID, Name: tag.Name})
+	}
+
+	encoded, _ := json.Marshal(revisionTags)
+	r.Tags = string(encoded)
+}
+
+func (r NewsRevision) RevisionTags() []RevisionTag {
+	revisionTags := []RevisionTag{}
+
+	_ = json.Unmarshal([]byte(r.Tags), &revisionTags)
+
+	return revisionTags
+}
+
+func (r NewsRevision) TagIDs() []int {
+	ids := []int{}
+
+	for _, tag := range r.RevisionTags() {
+		ids = append(ids, int(tag.ID))
+	}
+
+	return ids
+}
@@ -0,0 +1,66 @@
+package entity
+
+type Role string
+
+const (
+	RoleWriter Role = "writer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+// Permission is an action only some roles may take
+type Permission string
+
+const (
+	// PermNewsReadAny allows reading news in every status, not only the published ones
+	PermNewsReadAny Permission = "news:read_any"
+	// PermNewsWrite allows creating and editing news and moving them between draft and review
+	PermNewsWrite Permission = "news:write"
+	// PermNewsEditAny allows editing news without being one of their authors
+	PermNewsEditAny Permission = "news:edit_any"
+	// PermNewsPublish allows publishing, unpublishing and archiving news
+	PermNewsPublish Permission = "news:publish"
+	// PermNewsDelete allows moving news to the trash and back
+	PermNewsDelete Permission = "news:delete"
+	// PermNewsPurge allows deleting news permanently
+	PermNewsPurge Permission = "news:purge"
+	// PermTagsManage allows creating, editing, merging and deleting tags
+	PermTagsManage Permission = "tags:manage"
+	// PermAPIKeysManage allows issuing and revoking API keys
+	PermAPIKeysManage Permission = "apikeys:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleWriter: {PermNewsReadAny, PermNewsWrite},
+	RoleEditor: {PermNewsReadAny, PermNewsWrite, PermNewsEditAny, PermNewsPublish, PermNewsDelete},
+	RoleAdmin:  {PermNewsReadAny, PermNewsWrite, PermNewsEditAny, PermNewsPublish, PermNewsDelete, PermNewsPurge, PermTagsManage, PermAPIKeysManage},
+}
+
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+func (r Role) Can(permission Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TransitionPermission returns the permission needed to move a news from one
+// status to another. Anything going in or out of the published statuses
+// needs PermNewsPublish, anything going in or out of the trash PermNewsDelete.
+func TransitionPermission(from, to NewsStatus) Permission {
+	switch {
+	case from == StatusDeleted || to == StatusDeleted:
+		return PermNewsDelete
+	case from == StatusPublish || from == StatusArchived || to == StatusPublish || to == StatusArchived:
+		return PermNewsPublish
+	}
+
+	return PermNewsWrite
+}
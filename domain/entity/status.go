@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+type NewsStatus string
+
+const (
+	StatusDraft    NewsStatus = "draft"
+	StatusReview   NewsStatus = "review"
+	StatusPublish  NewsStatus = "publish"
+	StatusArchived NewsStatus = "archived"
+	StatusDeleted  NewsStatus = "deleted"
+)
+
+var ErrInvalidTransition = fmt.Errorf("%w: invalid status transition", ErrConflict)
+
+// statusTransitions lists the statuses a news can move to from each status
+var statusTransitions = map[NewsStatus][]NewsStatus{
+	StatusDraft:    {StatusReview, StatusPublish, StatusDeleted},
+	StatusReview:   {StatusDraft, StatusPublish, StatusDeleted},
+	StatusPublish:  {StatusDraft, StatusArchived, StatusDeleted},
+	StatusArchived: {StatusPublish, StatusDraft, StatusDeleted},
+	StatusDeleted:  {StatusDraft},
+}
+
+func (s NewsStatus) Valid() bool {
+	_, ok := statusTransitions[s]
+
+	return ok
+}
+
+func (s NewsStatus) CanTransitionTo(next NewsStatus) bool {
+	for _, status := range statusTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TransitionTo returns ErrInvalidTransition when next is not reachable from s
+func (s NewsStatus) TransitionTo(next NewsStatus) error {
+	if !s.CanTransitionTo(next) {
+		return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, s, next)
+	}
+
+	return nil
+}
+
+type NewsStatusHistory struct {
+	ID         uint `gorm:"primaryKey"`
+	NewsID     uint `gorm:"index"`
+	FromStatus NewsStatus
+	ToStatus   NewsStatus
+	CreatedAt  time.Time
+}
@@ -2,7 +2,47 @@ package entity
 
 import "gorm.io/gorm"
 
+// Tag names are unique within a tenant regardless of case, their slugs get a
+// number when names share one, e.g. C++ and C# are c and c-2
 type Tag struct {
 	gorm.Model
-	Name string
+	TenantID     uint   `gorm:"not null;default:1;uniqueIndex:idx_tags_tenant_name,priority:1;index:idx_tags_tenant_slug,priority:1"`
+	Name         string `gorm:"size:100"`
+	NameKey      string `gorm:"size:100;uniqueIndex:idx_tags_tenant_name,priority:2"`
+	Slug         string `gorm:"size:120;index:idx_tags_tenant_slug,priority:2"`
+	ParentID     *uint  `gorm:"index"`
+	Version      uint   `gorm:"not null;default:1"`
+	Translations []TagTranslation
+	// NewsCount is only filled by queries that count the news of a tag
+	NewsCount int64 `gorm:"->;-:migration"`
+}
+
+// TagDescendants returns the ids of the given tags and of all tags below them
+func TagDescendants(tags []Tag, ids []uint) []uint {
+	children := map[uint][]uint{}
+
+	for _, tag := range tags {
+		if tag.ParentID != nil {
+			children[*tag.ParentID] = append(children[*tag.ParentID], tag.ID)
+		}
+	}
+
+	result := []uint{}
+	seen := map[uint]bool{}
+	queue := append([]uint{}, ids...)
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if seen[id] {
+			continue
+		}
+
+		seen[id] = true
+		result = append(result, id)
+		queue = append(queue, children[id]...)
+	}
+
+	return result
 }
@@ -0,0 +1,16 @@
+package entity
+
+import "gorm.io/gorm"
+
+// DefaultTenantID is the tenant of requests matching no other tenant, and of
+// the news and tags created before tenants existed
+const DefaultTenantID uint = 1
+
+// Tenant is a publication, every news and tag belongs to one. Requests pick
+// their tenant with the X-Tenant header or by the host they are sent to.
+type Tenant struct {
+	gorm.Model
+	Name string `gorm:"size:100"`
+	Slug string `gorm:"size:100;uniqueIndex"`
+	Host string `gorm:"size:255;index"`
+}
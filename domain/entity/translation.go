@@ -0,0 +1,55 @@
+package entity
+
+import "time"
+
+// NewsTranslation is the title and body of a news in another locale than
+// the default one, which the news itself is written in
+type NewsTranslation struct {
+	ID          uint   `gorm:"primaryKey"`
+	TenantID    uint   `gorm:"not null;default:1;index"`
+	NewsID      uint   `gorm:"uniqueIndex:idx_news_translations_news_locale,priority:1"`
+	Locale      string `gorm:"size:10;uniqueIndex:idx_news_translations_news_locale,priority:2"`
+	Title       string
+	Body        string
+	BodyHTML    string
+	Summary     string
+	Excerpt     string
+	WordCount   int `gorm:"not null;default:0"`
+	ReadingTime int `gorm:"not null;default:0"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// TagTranslation is the name of a tag in another locale than the default one
+type TagTranslation struct {
+	ID        uint   `gorm:"primaryKey"`
+	TenantID  uint   `gorm:"not null;default:1;index"`
+	TagID     uint   `gorm:"uniqueIndex:idx_tag_translations_tag_locale,priority:1"`
+	Locale    string `gorm:"size:10;uniqueIndex:idx_tag_translations_tag_locale,priority:2"`
+	Name      string `gorm:"size:100"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Translation returns the translation of the news in a locale, if any
+func (n News) Translation(locale string) (NewsTranslation, bool) {
+	for _, translation := range n.Translations {
+		if translation.Locale == locale {
+			return translation, true
+		}
+	}
+
+	return NewsTranslation{}, false
+}
+
+// LocalizedName returns the name of the tag in a locale, or its own name
+// when it is not translated to that locale
+func (t Tag) LocalizedName(locale string) string {
+	for _, translation := range t.Translations {
+		if translation.Locale == locale {
+			return translation.Name
+		}
+	}
+
+	return t.Name
+}
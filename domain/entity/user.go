@@ -0,0 +1,13 @@
+package entity
+
+import "gorm.io/gorm"
+
+// User logs in to the tenant it belongs to, usernames are unique within a
+// tenant
+type User struct {
+	gorm.Model
+	TenantID uint   `gorm:"not null;default:1;uniqueIndex:idx_users_tenant_username,priority:1"`
+	Username string `gorm:"size:100;uniqueIndex:idx_users_tenant_username,priority:2"`
+	Password string `gorm:"size:100"`
+	Role     Role   `gorm:"size:20;default:writer"`
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"gorm.io/gorm"
+)
+
+type APIKeyInterface interface {
+	ForTenant(tenantID uint) APIKeyInterface
+	Create(key entity.APIKey) (entity.APIKey, error)
+	ReadAll() ([]entity.APIKey, error)
+	ReadByHash(hash string) (entity.APIKey, error)
+	Revoke(id int) (entity.APIKey, error)
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *apiKeyRepository {
+	registerTenantScope(db)
+
+	return &apiKeyRepository{db: db}
+}
+
+// ForTenant returns a repository only seeing the keys of one tenant
+func (kr *apiKeyRepository) ForTenant(tenantID uint) APIKeyInterface {
+	return &apiKeyRepository{db: forTenant(kr.db, tenantID)}
+}
+
+func (kr *apiKeyRepository) Create(key entity.APIKey) (entity.APIKey, error) {
+	if err := kr.db.Create(&key).Error; err != nil {
+		return key, translateError(err)
+	}
+
+	return key, nil
+}
+
+func (kr *apiKeyRepository) ReadAll() ([]entity.APIKey, error) {
+	var keys []entity.APIKey
+
+	if err := kr.db.Order("id").Find(&keys).Error; err != nil {
+		return keys, translateError(err)
+	}
+
+	return keys, nil
+}
+
+func (kr *apiKeyRepository) ReadByHash(hash string) (entity.APIKey, error) {
+	var key entity.APIKey
+
+	if err := kr.db.Where("hash = ?", hash).First(&key).Error; err != nil {
+		return key, translateError(err)
+	}
+
+	return key, nil
+}
+
+// Revoke stops a key from working, revoking a key twice keeps the first time
+func (kr *apiKeyRepository) Revoke(id int) (entity.APIKey, error) {
+	var key entity.APIKey
+
+	if err := kr.db.First(&key, id).Error; err != nil {
+		return key, translateError(err)
+	}
+
+	if key.RevokedAt != nil {
+		return key, nil
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+
+	if err := kr.db.Model(&key).Update("revoked_at", now).Error; err != nil {
+		return key, translateError(err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"gorm.io/gorm"
+)
+
+// withNewsCount selects tags along with the number of news, not trashed and
+// optionally in the given status, tagged with them
+func withNewsCount(db *gorm.DB, status entity.NewsStatus) *gorm.DB {
+	newsJoin := "LEFT JOIN news ON news.id = news_tags.news_id AND news.deleted_at IS NULL"
+	args := []interface{}{}
+
+	if status != "" {
+		newsJoin += " AND news.status = ?"
+		args = append(args, status)
+	}
+
+	return db.Model(&entity.Tag{}).
+		Select("tags.*, COUNT(news.id) AS news_count").
+		Joins("LEFT JOIN news_tags ON news_tags.tag_id = tags.id").
+		Joins(newsJoin, args...).
+		Group("tags.id")
+}
+
+// countNewsByTags returns the number of published news per tag, for every
+// tag when no tag ids are given
+func countNewsByTags(db *gorm.DB, tagIDs []uint) (map[uint]int64, error) {
+	var tags []entity.Tag
+
+	query := withNewsCount(db, entity.StatusPublish)
+
+	if len(tagIDs) > 0 {
+		query = query.Where("tags.id IN ?", tagIDs)
+	}
+
+	if err := query.Find(&tags).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	counts := map[uint]int64{}
+
+	for _, tag := range tags {
+		counts[tag.ID] = tag.NewsCount
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+const (
+	mysqlDuplicateEntry    = 1062
+	mysqlForeignKeyFailure = 1452
+)
+
+// translateError maps gorm and driver errors to the typed errors in entity
+func translateError(err error) error {
+	var mysqlErr *mysql.MySQLError
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return entity.ErrNotFound
+	case errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry:
+		return fmt.Errorf("%w: %s", entity.ErrConflict, mysqlErr.Message)
+	}
+
+	return err
+}
+
+// translateTagError reports foreign key failures on news_tags as an unknown tag
+func translateTagError(err error, tagID int) error {
+	var mysqlErr *mysql.MySQLError
+
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlForeignKeyFailure {
+		return entity.UnknownTagError{IDs: []int{tagID}}
+	}
+
+	return translateError(err)
+}
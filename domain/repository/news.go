@@ -1,19 +1,47 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type NewsInterface interface {
-	Create(news entity.News, tags []int) (entity.News, error)
+	ForTenant(tenantID uint) NewsInterface
+	Create(news entity.News, tags entity.TagSelection, authorIDs []uint) (entity.News, error)
 	ReadAll(filter entity.NewsFilter) ([]entity.News, error)
 	ReadOne(id int) (entity.News, error)
-	Edit(id int, newNews entity.News, tags []int) (entity.News, error)
+	ReadBySlug(slug string) (entity.News, error)
+	ReadRelated(id int, limit int) ([]entity.News, error)
+	ReadByIDs(ids []uint) ([]entity.News, error)
+	AddViews(views map[uint]int64) error
+	Edit(id int, newNews entity.News, tags entity.TagSelection, authorIDs []uint, schedule entity.ScheduleChange) (entity.News, error)
 	Delete(id int) (entity.News, error)
-	SetStatusDeleted(id int) (entity.News, error)
-	SetStatusPublish(id int) (entity.News, error)
-	SetStatusDraft(id int) (entity.News, error)
+	SetStatusDeleted(id int, editor string) (entity.News, error)
+	SetStatusPublish(id int, editor string) (entity.News, error)
+	SetStatusDraft(id int, editor string) (entity.News, error)
+	SetStatus(id int, status entity.NewsStatus, editor string) (entity.News, error)
+	ReadStatusHistory(id int) ([]entity.NewsStatusHistory, error)
+	ReadDuePublish(now time.Time) ([]entity.News, error)
+	ReadDueUnpublish(now time.Time) ([]entity.News, error)
+	PublishScheduled(news entity.News, editor string) (bool, error)
+	UnpublishScheduled(news entity.News, editor string) (bool, error)
+	ReadTranslations(id int) ([]entity.NewsTranslation, error)
+	SaveTranslation(id int, translation entity.NewsTranslation) (entity.NewsTranslation, error)
+	ReadRevisions(id int) ([]entity.NewsRevision, error)
+	ReadRevision(id int, number int) (entity.NewsRevision, error)
+	RestoreRevision(id int, number int, editor string) (entity.News, error)
+	ReadTrash() ([]entity.News, error)
+	Restore(id int, editor string) (entity.News, error)
+	Purge(id int) (entity.News, error)
+	PurgeTrash(before time.Time) (int64, error)
+	CountByTags(tagIDs []uint) (map[uint]int64, error)
 }
 
 type newsRepository struct {
@@ -21,25 +49,46 @@ type newsRepository struct {
 }
 
 func NewNewsRepository(db *gorm.DB) *newsRepository {
+	registerTenantScope(db)
+
 	return &newsRepository{db: db}
 }
 
-func (nr *newsRepository) Create(news entity.News, tags []int) (entity.News, error) {
+// ForTenant returns a repository only seeing the news of one tenant
+func (nr *newsRepository) ForTenant(tenantID uint) NewsInterface {
+	return &newsRepository{db: forTenant(nr.db, tenantID)}
+}
+
+// Create stores a news with its tags and authors
+func (nr *newsRepository) Create(news entity.News, tags entity.TagSelection, authorIDs []uint) (entity.News, error) {
 	if err := nr.db.Transaction(func(tx *gorm.DB) error {
+		tagIDs, err := resolveTags(tx, tags)
+		if err != nil {
+			return err
+		}
+
+		news.Slug, err = newsSlug(tx, news.ID, news.Title)
+		if err != nil {
+			return err
+		}
+
+		if err := renderBody(&news); err != nil {
+			return err
+		}
+
 		if err := tx.Create(&news).Error; err != nil {
+			return translateError(err)
+		}
+
+		if err := createNewsTags(tx, news.ID, tagIDs); err != nil {
 			return err
 		}
 
-		for _, tag := range tags {
-			if err := tx.Create(&entity.NewsTags{
-				NewsID: news.ID,
-				TagID:  uint(tag),
-			}).Error; err != nil {
-				return err
-			}
+		if err := setNewsAuthors(tx, news.ID, authorIDs); err != nil {
+			return err
 		}
 
-		return nil
+		return recordRevision(tx, &news, entity.RevisionCreate, news.EditedBy)
 
 	}); err != nil {
 		return news, err
@@ -51,53 +100,249 @@ func (nr *newsRepository) Create(news entity.News, tags []int) (entity.News, err
 func (nr *newsRepository) ReadAll(filter entity.NewsFilter) ([]entity.News, error) {
 	var news []entity.News
 
-	if filter.Tags[0] != "" && filter.Status != "" {
-		nr.db.Preload("Tags", "name IN ?", filter.Tags).Where("status = ?", filter.Status).Find(&news)
-	} else if filter.Tags[0] != "" {
-		nr.db.Preload("Tags", "name IN ?", filter.Tags).Find(&news)
-	} else if filter.Status != "" {
-		nr.db.Preload("Tags").Where("status = ?", filter.Status).Find(&news)
+	query := nr.db
+
+	if filter.Tags[0] != "" {
+		tagIDs, err := nr.topicTagIDs(filter.Tags, filter.IncludeChildren)
+		if err != nil {
+			return news, err
+		}
+
+		query = query.Preload("Tags", "id IN ?", tagIDs)
 	} else {
-		nr.db.Preload("Tags").Find(&news)
+		query = query.Preload("Tags")
+	}
+
+	query = query.Preload("Tags.Translations").Preload("Authors").Preload("Translations")
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.Author != "" {
+		authored := nr.db.Table("news_authors").
+			Select("news_authors.news_id").
+			Joins("JOIN users ON users.id = news_authors.user_id").
+			Where("users.username = ?", strings.ToLower(strings.TrimSpace(filter.Author)))
+
+		query = query.Where("id IN (?)", authored)
+	}
+
+	if err := query.Find(&news).Error; err != nil {
+		return news, translateError(err)
 	}
 
 	return news, nil
 }
 
+// topicTagIDs finds the tags matching topic names or slugs, along with
+// their descendants when includeChildren is set
+func (nr *newsRepository) topicTagIDs(topics []string, includeChildren bool) ([]uint, error) {
+	names := []string{}
+	slugs := []string{}
+
+	// slugs are matched as given, C# and C++ share the slug c before its suffix
+	for _, topic := range topics {
+		names = append(names, utils.NameKey(topic))
+		slugs = append(slugs, strings.ToLower(strings.TrimSpace(topic)))
+	}
+
+	var tagIDs []uint
+
+	if err := nr.db.Model(&entity.Tag{}).Where("name_key IN ? OR slug IN ?", names, slugs).Pluck("id", &tagIDs).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	if !includeChildren || len(tagIDs) == 0 {
+		return tagIDs, nil
+	}
+
+	var tags []entity.Tag
+
+	if err := nr.db.Select("id", "parent_id").Find(&tags).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	return entity.TagDescendants(tags, tagIDs), nil
+}
+
 func (nr *newsRepository) ReadOne(id int) (entity.News, error) {
 	var news entity.News
 
-	if err := nr.db.Preload("Tags").First(&news, id).Error; err != nil {
+	if err := preloadNews(nr.db).First(&news, id).Error; err != nil {
+		return news, translateError(err)
+	}
+
+	return news, nil
+}
+
+// ReadBySlug finds a news by its current slug or by one of its former slugs.
+// The returned news carries its current slug, so callers can tell them apart.
+func (nr *newsRepository) ReadBySlug(slug string) (entity.News, error) {
+	var news entity.News
+
+	err := preloadNews(nr.db).Where("slug = ?", slug).First(&news).Error
+	if err == nil {
+		return news, nil
+	}
+
+	if err := translateError(err); err != entity.ErrNotFound {
 		return news, err
 	}
 
+	var former entity.NewsSlug
+
+	if err := nr.db.Where("slug = ?", slug).First(&former).Error; err != nil {
+		return news, translateError(err)
+	}
+
+	if err := preloadNews(nr.db).First(&news, former.NewsID).Error; err != nil {
+		return news, translateError(err)
+	}
+
 	return news, nil
 }
 
-func (nr *newsRepository) Edit(id int, newNews entity.News, tags []int) (entity.News, error) {
+// ReadRelated returns other published news sharing tags with a news, the
+// ones sharing the most tags first and then the most recent ones
+func (nr *newsRepository) ReadRelated(id int, limit int) ([]entity.News, error) {
+	var news []entity.News
+
+	if err := nr.db.First(&entity.News{}, id).Error; err != nil {
+		return news, translateError(err)
+	}
+
+	tagIDs := nr.db.Model(&entity.NewsTags{}).Select("tag_id").Where("news_id = ?", id)
+
+	if err := preloadNews(nr.db).
+		Select("news.*, COUNT(news_tags.tag_id) AS shared_tags").
+		Joins("JOIN news_tags ON news_tags.news_id = news.id").
+		Where("news_tags.tag_id IN (?)", tagIDs).
+		Where("news.id <> ? AND news.status = ?", id, entity.StatusPublish).
+		Group("news.id").
+		Order("shared_tags DESC, news.created_at DESC, news.id DESC").
+		Limit(limit).
+		Find(&news).Error; err != nil {
+		return news, translateError(err)
+	}
+
+	return news, nil
+}
+
+func (nr *newsRepository) ReadByIDs(ids []uint) ([]entity.News, error) {
+	var news []entity.News
+
+	if err := preloadNews(nr.db).Where("id IN ?", ids).Find(&news).Error; err != nil {
+		return news, translateError(err)
+	}
+
+	return news, nil
+}
+
+// AddViews adds view counts, by news id, to the view count of news
+func (nr *newsRepository) AddViews(views map[uint]int64) error {
+	return nr.db.Transaction(func(tx *gorm.DB) error {
+		for id, count := range views {
+			if err := tx.Unscoped().Model(&entity.News{}).
+				Where("id = ?", id).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", count)).Error; err != nil {
+				return translateError(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Edit updates a news and its tags, its authors are only replaced when
+// authorIDs is not nil
+func (nr *newsRepository) Edit(id int, newNews entity.News, tags entity.TagSelection, authorIDs []uint, schedule entity.ScheduleChange) (entity.News, error) {
 	var news entity.News
 
 	if err := nr.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.First(&news, id).Error; err != nil {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&news, id).Error; err != nil {
+			return translateError(err)
+		}
+
+		if err := entity.CheckVersion(news.Version, newNews.Version); err != nil {
 			return err
 		}
 
-		nr.db.Model(&news).Updates(newNews)
+		tagIDs, err := resolveTags(tx, tags)
+		if err != nil {
+			return err
+		}
+
+		if err := changeSlug(tx, &news, &newNews); err != nil {
+			return err
+		}
+
+		// Updates skips zero values, the columns an edit may zero are selected
+		columns := []string{}
+
+		if newNews.Body != "" || newNews.BodyFormat != "" || newNews.Summary != nil {
+			if newNews.Body == "" {
+				newNews.Body = news.Body
+			}
+
+			if newNews.BodyFormat == "" {
+				newNews.BodyFormat = news.BodyFormat
+			}
+
+			if newNews.Summary == nil {
+				newNews.Summary = news.Summary
+			}
+
+			if err := renderBody(&newNews); err != nil {
+				return err
+			}
+
+			columns = append(columns, "Body", "BodyFormat", "BodyHTML", "Summary", "Excerpt", "WordCount", "ReadingTime")
+
+			if newNews.BodyFormat != news.BodyFormat {
+				if err := renderTranslations(tx, news.ID, newNews.BodyFormat); err != nil {
+					return err
+				}
+			}
+		}
+
+		newNews.Version = news.Version + 1
+
+		if err := tx.Model(&news).Updates(newNews).Error; err != nil {
+			return translateError(err)
+		}
+
+		newNews.PublishAt, newNews.UnpublishAt = schedule.PublishAt, schedule.UnpublishAt
+
+		if schedule.SetPublishAt {
+			columns = append(columns, "PublishAt")
+		}
+
+		if schedule.SetUnpublishAt {
+			columns = append(columns, "UnpublishAt")
+		}
+
+		if len(columns) > 0 {
+			if err := tx.Model(&news).Select(columns).Updates(newNews).Error; err != nil {
+				return translateError(err)
+			}
+		}
 
 		if err := tx.Delete(&entity.NewsTags{}, "news_id = ?", news.ID).Error; err != nil {
+			return translateError(err)
+		}
+
+		if err := createNewsTags(tx, news.ID, tagIDs); err != nil {
 			return err
 		}
 
-		for _, tag := range tags {
-			if err := tx.Create(&entity.NewsTags{
-				NewsID: news.ID,
-				TagID:  uint(tag),
-			}).Error; err != nil {
+		if authorIDs != nil {
+			if err := setNewsAuthors(tx, news.ID, authorIDs); err != nil {
 				return err
 			}
 		}
 
-		return nil
+		return recordRevision(tx, &news, entity.RevisionEdit, newNews.EditedBy)
 
 	}); err != nil {
 		return news, err
@@ -109,47 +354,778 @@ func (nr *newsRepository) Edit(id int, newNews entity.News, tags []int) (entity.
 func (nr *newsRepository) Delete(id int) (entity.News, error) {
 	var news entity.News
 
-	if err := nr.db.First(&news, id).Error; err != nil {
-		return news, err
+	if err := nr.db.Preload("Tags").First(&news, id).Error; err != nil {
+		return news, translateError(err)
 	}
 
-	nr.db.Delete(&news, id)
+	if err := nr.db.Delete(&news, id).Error; err != nil {
+		return news, translateError(err)
+	}
 
 	return news, nil
 }
 
-func (nr *newsRepository) SetStatusDeleted(id int) (entity.News, error) {
+func (nr *newsRepository) SetStatusDeleted(id int, editor string) (entity.News, error) {
+	return nr.SetStatus(id, entity.StatusDeleted, editor)
+}
+
+func (nr *newsRepository) SetStatusPublish(id int, editor string) (entity.News, error) {
+	return nr.SetStatus(id, entity.StatusPublish, editor)
+}
+
+func (nr *newsRepository) SetStatusDraft(id int, editor string) (entity.News, error) {
+	return nr.SetStatus(id, entity.StatusDraft, editor)
+}
+
+func (nr *newsRepository) SetStatus(id int, status entity.NewsStatus, editor string) (entity.News, error) {
 	var news entity.News
 
-	if err := nr.db.First(&news, id).Error; err != nil {
+	if err := nr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&news, id).Error; err != nil {
+			return translateError(err)
+		}
+
+		// a status changed by hand overrides the schedule
+		if err := tx.Model(&news).Updates(map[string]interface{}{"publish_at": nil, "unpublish_at": nil}).Error; err != nil {
+			return translateError(err)
+		}
+
+		return changeStatus(tx, &news, status, editor)
+
+	}); err != nil {
 		return news, err
 	}
 
-	nr.db.Model(&news).Update("status", "deleted")
+	return news, nil
+}
+
+// changeStatus moves a locked news to a status and records the transition
+func changeStatus(tx *gorm.DB, news *entity.News, status entity.NewsStatus, editor string) error {
+	from := news.Status
+
+	if err := from.TransitionTo(status); err != nil {
+		return err
+	}
+
+	updates := entity.News{
+		Status:   status,
+		EditedBy: editor,
+		Version:  news.Version + 1,
+	}
+
+	// published_at is when the news was last published
+	if status == entity.StatusPublish {
+		now := time.Now()
+		updates.PublishedAt = &now
+	}
+
+	if err := tx.Model(news).Updates(updates).Error; err != nil {
+		return translateError(err)
+	}
+
+	if err := tx.Create(&entity.NewsStatusHistory{
+		NewsID:     news.ID,
+		FromStatus: from,
+		ToStatus:   status,
+	}).Error; err != nil {
+		return translateError(err)
+	}
+
+	return recordRevision(tx, news, entity.RevisionStatus, editor)
+}
+
+func (nr *newsRepository) ReadStatusHistory(id int) ([]entity.NewsStatusHistory, error) {
+	var history []entity.NewsStatusHistory
+
+	if err := nr.db.First(&entity.News{}, id).Error; err != nil {
+		return history, translateError(err)
+	}
+
+	if err := nr.db.Where("news_id = ?", id).Order("id").Find(&history).Error; err != nil {
+		return history, translateError(err)
+	}
+
+	return history, nil
+}
+
+func (nr *newsRepository) ReadDuePublish(now time.Time) ([]entity.News, error) {
+	var news []entity.News
+
+	if err := nr.db.Where("publish_at <= ?", now).Find(&news).Error; err != nil {
+		return news, translateError(err)
+	}
 
 	return news, nil
 }
 
-func (nr *newsRepository) SetStatusPublish(id int) (entity.News, error) {
+func (nr *newsRepository) ReadDueUnpublish(now time.Time) ([]entity.News, error) {
+	var news []entity.News
+
+	if err := nr.db.Where("unpublish_at <= ?", now).Find(&news).Error; err != nil {
+		return news, translateError(err)
+	}
+
+	return news, nil
+}
+
+// PublishScheduled publishes a news whose publish schedule was read earlier
+// and clears the schedule in the same transaction, so a failed publish keeps
+// it. Only one caller gets true for a schedule, so several instances never
+// publish the same news twice. Only a draft or a news in review is published,
+// the schedule of any other news is just cleared.
+func (nr *newsRepository) PublishScheduled(news entity.News, editor string) (bool, error) {
+	return nr.runSchedule(news.ID, "publish_at", news.PublishAt, entity.StatusPublish, editor, entity.StatusDraft, entity.StatusReview)
+}
+
+// UnpublishScheduled is the unpublish counterpart of PublishScheduled, it
+// only moves a published news back to draft
+func (nr *newsRepository) UnpublishScheduled(news entity.News, editor string) (bool, error) {
+	return nr.runSchedule(news.ID, "unpublish_at", news.UnpublishAt, entity.StatusDraft, editor, entity.StatusPublish)
+}
+
+func (nr *newsRepository) runSchedule(id uint, column string, due *time.Time, status entity.NewsStatus, editor string, from ...entity.NewsStatus) (bool, error) {
+	if due == nil {
+		return false, nil
+	}
+
+	claimed := false
+
+	err := nr.db.Transaction(func(tx *gorm.DB) error {
+		var news entity.News
+
+		// the schedule changed or was run by another instance since it was read
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(column+" = ?", due).First(&news, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+
+		if err != nil {
+			return translateError(err)
+		}
+
+		if err := tx.Model(&news).Update(column, nil).Error; err != nil {
+			return translateError(err)
+		}
+
+		// a news in any other status only has its schedule cleared
+		if !containsStatus(from, news.Status) {
+			return nil
+		}
+
+		claimed = true
+
+		return changeStatus(tx, &news, status, editor)
+	})
+
+	return claimed && err == nil, err
+}
+
+func (nr *newsRepository) ReadTranslations(id int) ([]entity.NewsTranslation, error) {
+	var translations []entity.NewsTranslation
+
+	if err := nr.db.First(&entity.News{}, id).Error; err != nil {
+		return translations, translateError(err)
+	}
+
+	if err := nr.db.Where("news_id = ?", id).Order("locale").Find(&translations).Error; err != nil {
+		return translations, translateError(err)
+	}
+
+	return translations, nil
+}
+
+// SaveTranslation adds the translation of a news to a locale, or replaces
+// the one it already has
+func (nr *newsRepository) SaveTranslation(id int, translation entity.NewsTranslation) (entity.NewsTranslation, error) {
+	if err := nr.db.Transaction(func(tx *gorm.DB) error {
+		var news entity.News
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&news, id).Error; err != nil {
+			return translateError(err)
+		}
+
+		var existing entity.NewsTranslation
+
+		err := tx.Where("news_id = ? AND locale = ?", news.ID, translation.Locale).First(&existing).Error
+		if err := translateError(err); err != nil && err != entity.ErrNotFound {
+			return err
+		}
+
+		if err := renderTranslation(&translation, news.BodyFormat); err != nil {
+			return err
+		}
+
+		translation.ID = existing.ID
+		translation.NewsID = news.ID
+		translation.CreatedAt = existing.CreatedAt
+
+		return translateError(tx.Save(&translation).Error)
+
+	}); err != nil {
+		return translation, err
+	}
+
+	return translation, nil
+}
+
+func (nr *newsRepository) ReadRevisions(id int) ([]entity.NewsRevision, error) {
+	var revisions []entity.NewsRevision
+
+	if err := nr.db.First(&entity.News{}, id).Error; err != nil {
+		return revisions, translateError(err)
+	}
+
+	if err := nr.db.Where("news_id = ?", id).Order("number").Find(&revisions).Error; err != nil {
+		return revisions, translateError(err)
+	}
+
+	return revisions, nil
+}
+
+func (nr *newsRepository) ReadRevision(id int, number int) (entity.NewsRevision, error) {
+	var revision entity.NewsRevision
+
+	if err := nr.db.First(&entity.News{}, id).Error; err != nil {
+		return revision, translateError(err)
+	}
+
+	if err := nr.db.Where("news_id = ? AND number = ?", id, number).First(&revision).Error; err != nil {
+		return revision, translateError(err)
+	}
+
+	return revision, nil
+}
+
+// RestoreRevision makes the title, body and tags of a revision the current
+// version. The status is left as is, since it only changes through SetStatus.
+func (nr *newsRepository) RestoreRevision(id int, number int, editor string) (entity.News, error) {
 	var news entity.News
 
-	if err := nr.db.First(&news, id).Error; err != nil {
+	if err := nr.db.Transaction(func(tx *gorm.DB) error {
+		var revision entity.NewsRevision
+
+		if err := tx.Where("news_id = ? AND number = ?", id, number).First(&revision).Error; err != nil {
+			return translateError(err)
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&news, id).Error; err != nil {
+			return translateError(err)
+		}
+
+		tagIDs, err := resolveTags(tx, entity.TagSelection{IDs: revision.TagIDs()})
+		if err != nil {
+			return err
+		}
+
+		restored := entity.News{
+			Title:      revision.Title,
+			Body:       revision.Body,
+			BodyFormat: revision.BodyFormat,
+			Summary:    news.Summary,
+			EditedBy:   editor,
+			Version:    news.Version + 1,
+		}
+
+		if err := changeSlug(tx, &news, &restored); err != nil {
+			return err
+		}
+
+		if err := renderBody(&restored); err != nil {
+			return err
+		}
+
+		if restored.BodyFormat != news.BodyFormat {
+			if err := renderTranslations(tx, news.ID, restored.BodyFormat); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&news).Select("Title", "Slug", "Body", "BodyFormat", "BodyHTML", "Excerpt", "WordCount", "ReadingTime", "EditedBy", "Version").Updates(restored).Error; err != nil {
+			return translateError(err)
+		}
+
+		if err := tx.Delete(&entity.NewsTags{}, "news_id = ?", news.ID).Error; err != nil {
+			return translateError(err)
+		}
+
+		if err := createNewsTags(tx, news.ID, tagIDs); err != nil {
+			return err
+		}
+
+		return recordRevision(tx, &news, entity.RevisionRestore, editor)
+
+	}); err != nil {
 		return news, err
 	}
 
-	nr.db.Model(&news).Update("status", "publish")
+	return news, nil
+}
+
+func (nr *newsRepository) ReadTrash() ([]entity.News, error) {
+	var news []entity.News
+
+	if err := preloadNews(nr.db.Unscoped()).Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&news).Error; err != nil {
+		return news, translateError(err)
+	}
 
 	return news, nil
 }
 
-func (nr *newsRepository) SetStatusDraft(id int) (entity.News, error) {
+// Restore brings a news back from the trash as a new version
+func (nr *newsRepository) Restore(id int, editor string) (entity.News, error) {
 	var news entity.News
 
-	if err := nr.db.First(&news, id).Error; err != nil {
+	if err := nr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("deleted_at IS NOT NULL").First(&news, id).Error; err != nil {
+			return translateError(err)
+		}
+
+		if err := tx.Unscoped().Model(&news).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"edited_by":  editor,
+			"version":    news.Version + 1,
+		}).Error; err != nil {
+			return translateError(err)
+		}
+
+		return recordRevision(tx, &news, entity.RevisionUndelete, editor)
+
+	}); err != nil {
 		return news, err
 	}
 
-	nr.db.Model(&news).Update("status", "draft")
+	return news, nil
+}
+
+// Purge permanently deletes a news, trashed or not, with its tags and history
+func (nr *newsRepository) Purge(id int) (entity.News, error) {
+	var news entity.News
+
+	if err := nr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Preload("Tags").First(&news, id).Error; err != nil {
+			return translateError(err)
+		}
+
+		return purgeNews(tx, []uint{news.ID})
+
+	}); err != nil {
+		return news, err
+	}
 
 	return news, nil
 }
+
+// PurgeTrash permanently deletes the news trashed before the given time
+func (nr *newsRepository) PurgeTrash(before time.Time) (int64, error) {
+	var ids []uint
+
+	if err := nr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&entity.News{}).Where("deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+			return translateError(err)
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		return purgeNews(tx, ids)
+
+	}); err != nil {
+		return 0, err
+	}
+
+	return int64(len(ids)), nil
+}
+
+func purgeNews(tx *gorm.DB, ids []uint) error {
+	if err := tx.Where("news_id IN ?", ids).Delete(&entity.NewsTags{}).Error; err != nil {
+		return translateError(err)
+	}
+
+	if err := tx.Where("news_id IN ?", ids).Delete(&entity.NewsStatusHistory{}).Error; err != nil {
+		return translateError(err)
+	}
+
+	if err := tx.Where("news_id IN ?", ids).Delete(&entity.NewsRevision{}).Error; err != nil {
+		return translateError(err)
+	}
+
+	if err := tx.Where("news_id IN ?", ids).Delete(&entity.NewsSlug{}).Error; err != nil {
+		return translateError(err)
+	}
+
+	if err := tx.Where("news_id IN ?", ids).Delete(&entity.NewsAuthors{}).Error; err != nil {
+		return translateError(err)
+	}
+
+	if err := tx.Where("news_id IN ?", ids).Delete(&entity.NewsTranslation{}).Error; err != nil {
+		return translateError(err)
+	}
+
+	if err := tx.Unscoped().Delete(&entity.News{}, ids).Error; err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (nr *newsRepository) CountByTags(tagIDs []uint) (map[uint]int64, error) {
+	return countNewsByTags(nr.db, tagIDs)
+}
+
+// renderBody sanitizes an HTML body, renders the body to HTML and works
+// out its excerpt and reading time. News without a format are plain text.
+func renderBody(news *entity.News) error {
+	if news.BodyFormat == "" {
+		news.BodyFormat = entity.FormatPlain
+	}
+
+	if news.BodyFormat == entity.FormatHTML {
+		if news.Body = utils.SanitizeHTML(news.Body); news.Body == "" {
+			return emptyBodyError()
+		}
+	}
+
+	var err error
+
+	news.BodyHTML, err = utils.RenderBody(news.BodyFormat, news.Body)
+	if err != nil {
+		return err
+	}
+
+	summary := ""
+	if news.Summary != nil {
+		summary = *news.Summary
+	}
+
+	words := utils.BodyWords(news.BodyHTML)
+
+	news.Excerpt = utils.Excerpt(summary, words)
+	news.WordCount = len(words)
+	news.ReadingTime = utils.ReadingTime(news.WordCount)
+
+	return nil
+}
+
+// renderTranslation renders the body of a translation in the format of its news
+func renderTranslation(translation *entity.NewsTranslation, format entity.BodyFormat) error {
+	if format == entity.FormatHTML {
+		if translation.Body = utils.SanitizeHTML(translation.Body); translation.Body == "" {
+			return emptyBodyError()
+		}
+	}
+
+	var err error
+
+	translation.BodyHTML, err = utils.RenderBody(format, translation.Body)
+	if err != nil {
+		return err
+	}
+
+	words := utils.BodyWords(translation.BodyHTML)
+
+	translation.Excerpt = utils.Excerpt(translation.Summary, words)
+	translation.WordCount = len(words)
+	translation.ReadingTime = utils.ReadingTime(translation.WordCount)
+
+	return nil
+}
+
+// renderTranslations renders the translations of a news again after the
+// format of its body changed
+func renderTranslations(tx *gorm.DB, newsID uint, format entity.BodyFormat) error {
+	var translations []entity.NewsTranslation
+
+	if err := tx.Where("news_id = ?", newsID).Find(&translations).Error; err != nil {
+		return translateError(err)
+	}
+
+	for _, translation := range translations {
+		if err := renderTranslation(&translation, format); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&translation).Select("Body", "BodyHTML", "Excerpt", "WordCount", "ReadingTime").Updates(translation).Error; err != nil {
+			return translateError(err)
+		}
+	}
+
+	return nil
+}
+
+func emptyBodyError() error {
+	return entity.ValidationError{Fields: []entity.FieldError{{
+		Field:   "body",
+		Message: "body has no content left once unsafe HTML is removed",
+	}}}
+}
+
+// preloadNews loads the tags, authors and translations of news
+func preloadNews(db *gorm.DB) *gorm.DB {
+	return db.Preload("Tags").Preload("Tags.Translations").Preload("Authors").Preload("Translations")
+}
+
+// newsSlug builds a slug from a title that no other news uses, now or in the
+// past. Slugs formerly used by the news itself may be taken back.
+func newsSlug(tx *gorm.DB, newsID uint, title string) (string, error) {
+	base := utils.TruncateSlug(utils.Slugify(title), entity.MaxNewsSlugLength)
+
+	return utils.UniqueSlug(base, "news", func(slug string) (bool, error) {
+		var count int64
+
+		if err := tx.Unscoped().Model(&entity.News{}).Where("slug = ? AND id <> ?", slug, newsID).Count(&count).Error; err != nil {
+			return false, translateError(err)
+		}
+
+		if count == 0 {
+			if err := tx.Model(&entity.NewsSlug{}).Where("slug = ? AND news_id <> ?", slug, newsID).Count(&count).Error; err != nil {
+				return false, translateError(err)
+			}
+		}
+
+		return count > 0, nil
+	})
+}
+
+// changeSlug gives newNews a slug for its title when the title changed, and
+// keeps the old slug of news in the history so it can be redirected. An empty
+// title is left unchanged by the edit.
+func changeSlug(tx *gorm.DB, news *entity.News, newNews *entity.News) error {
+	if newNews.Title == "" || newNews.Title == news.Title {
+		newNews.Slug = news.Slug
+		return nil
+	}
+
+	slug, err := newsSlug(tx, news.ID, newNews.Title)
+	if err != nil {
+		return err
+	}
+
+	newNews.Slug = slug
+
+	if slug == news.Slug {
+		return nil
+	}
+
+	if err := tx.Where("news_id = ? AND slug = ?", news.ID, slug).Delete(&entity.NewsSlug{}).Error; err != nil {
+		return translateError(err)
+	}
+
+	if news.Slug != "" {
+		if err := tx.Create(&entity.NewsSlug{NewsID: news.ID, Slug: news.Slug}).Error; err != nil {
+			return translateError(err)
+		}
+	}
+
+	return nil
+}
+
+// recordRevision reloads the news with its tags and stores it as a revision
+func recordRevision(tx *gorm.DB, news *entity.News, action string, editor string) error {
+	if err := tx.Preload("Tags").Preload("Authors").First(news, news.ID).Error; err != nil {
+		return translateError(err)
+	}
+
+	var last int
+
+	if err := tx.Model(&entity.NewsRevision{}).
+		Where("news_id = ?", news.ID).
+		Select("COALESCE(MAX(number), 0)").
+		Scan(&last).Error; err != nil {
+		return translateError(err)
+	}
+
+	revision := entity.NewsRevision{
+		NewsID:     news.ID,
+		Number:     last + 1,
+		Action:     action,
+		Editor:     editor,
+		Title:      news.Title,
+		Body:       news.Body,
+		BodyFormat: news.BodyFormat,
+		Status:     news.Status,
+	}
+	revision.SetTags(news.Tags)
+
+	if err := tx.Create(&revision).Error; err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// resolveTags dedupes the selected tags and returns their ids. Unknown or
+// soft-deleted tags are rejected, unless missing names may be created.
+func resolveTags(tx *gorm.DB, selection entity.TagSelection) ([]int, error) {
+	ids := []int{}
+	seenIDs := map[int]bool{}
+
+	for _, id := range selection.IDs {
+		if !seenIDs[id] {
+			seenIDs[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	unknown := entity.UnknownTagError{}
+
+	if len(ids) > 0 {
+		var found []int
+
+		if err := tx.Model(&entity.Tag{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+			return nil, translateError(err)
+		}
+
+		foundIDs := map[int]bool{}
+		for _, id := range found {
+			foundIDs[id] = true
+		}
+
+		for _, id := range ids {
+			if !foundIDs[id] {
+				unknown.IDs = append(unknown.IDs, id)
+			}
+		}
+	}
+
+	names := []string{}
+	seenNames := map[string]bool{}
+
+	for _, name := range selection.Names {
+		name = utils.NormalizeName(name)
+		key := utils.NameKey(name)
+
+		if utils.Slugify(name) != "" && !seenNames[key] {
+			seenNames[key] = true
+			names = append(names, name)
+		}
+	}
+
+	if len(names) > 0 {
+		keys := []string{}
+		for _, name := range names {
+			keys = append(keys, utils.NameKey(name))
+		}
+
+		var found []entity.Tag
+
+		if err := tx.Where("name_key IN ?", keys).Find(&found).Error; err != nil {
+			return nil, translateError(err)
+		}
+
+		foundNames := map[string]int{}
+		for _, tag := range found {
+			foundNames[tag.NameKey] = int(tag.ID)
+		}
+
+		for _, name := range names {
+			id, ok := foundNames[utils.NameKey(name)]
+
+			if !ok && selection.CreateMissing {
+				tag := entity.Tag{Name: name}
+
+				if err := normalizeTag(tx, &tag, 0); err != nil {
+					return nil, err
+				}
+
+				if err := tx.Create(&tag).Error; err != nil {
+					return nil, translateError(err)
+				}
+
+				id, ok = int(tag.ID), true
+			}
+
+			if !ok {
+				unknown.Names = append(unknown.Names, name)
+				continue
+			}
+
+			if !seenIDs[id] {
+				seenIDs[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	if len(unknown.IDs) > 0 || len(unknown.Names) > 0 {
+		return nil, unknown
+	}
+
+	return ids, nil
+}
+
+func createNewsTags(tx *gorm.DB, newsID uint, tagIDs []int) error {
+	for _, tag := range tagIDs {
+		if err := tx.Create(&entity.NewsTags{
+			NewsID: newsID,
+			TagID:  uint(tag),
+		}).Error; err != nil {
+			return translateTagError(err, tag)
+		}
+	}
+
+	return nil
+}
+
+// setNewsAuthors replaces the authors of a news, every author must be a user
+func setNewsAuthors(tx *gorm.DB, newsID uint, userIDs []uint) error {
+	ids := []uint{}
+	seen := map[uint]bool{}
+
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) > 0 {
+		var found []uint
+
+		if err := tx.Model(&entity.User{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+			return translateError(err)
+		}
+
+		foundIDs := map[uint]bool{}
+		for _, id := range found {
+			foundIDs[id] = true
+		}
+
+		fields := []entity.FieldError{}
+
+		for _, id := range ids {
+			if !foundIDs[id] {
+				fields = append(fields, entity.FieldError{
+					Field:   "authors",
+					Message: fmt.Sprintf("user %d does not exist", id),
+				})
+			}
+		}
+
+		if len(fields) > 0 {
+			return entity.ValidationError{Fields: fields}
+		}
+	}
+
+	if err := tx.Delete(&entity.NewsAuthors{}, "news_id = ?", newsID).Error; err != nil {
+		return translateError(err)
+	}
+
+	for _, id := range ids {
+		if err := tx.Create(&entity.NewsAuthors{NewsID: newsID, UserID: id}).Error; err != nil {
+			return translateError(err)
+		}
+	}
+
+	return nil
+}
+
+func containsStatus(statuses []entity.NewsStatus, status entity.NewsStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
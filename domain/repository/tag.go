@@ -1,15 +1,29 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TagInterface interface {
+	ForTenant(tenantID uint) TagInterface
 	Create(tag entity.Tag) (entity.Tag, error)
-	ReadAll() ([]entity.Tag, error)
+	ReadAll(status entity.NewsStatus) ([]entity.Tag, error)
+	ReadByIDs(ids []uint) ([]entity.Tag, error)
 	Edit(id int, newTag entity.Tag) (entity.Tag, error)
 	Delete(id int) (entity.Tag, error)
+	ReadTrash() ([]entity.Tag, error)
+	Restore(id int) (entity.Tag, error)
+	Purge(id int) (entity.Tag, error)
+	PurgeTrash(before time.Time) (int64, error)
+	Merge(targetID int, sourceIDs []int, newName string) (entity.Tag, error)
+	SaveTranslation(id int, translation entity.TagTranslation) (entity.TagTranslation, error)
 }
 
 type tagRepository struct {
@@ -17,21 +31,55 @@ type tagRepository struct {
 }
 
 func NewTagRepository(db *gorm.DB) *tagRepository {
+	registerTenantScope(db)
+
 	return &tagRepository{db: db}
 }
 
+// ForTenant returns a repository only seeing the tags of one tenant
+func (tr *tagRepository) ForTenant(tenantID uint) TagInterface {
+	return &tagRepository{db: forTenant(tr.db, tenantID)}
+}
+
 func (tr *tagRepository) Create(tag entity.Tag) (entity.Tag, error) {
-	if err := tr.db.Create(&tag).Error; err != nil {
+	if err := tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := normalizeTag(tx, &tag, 0); err != nil {
+			return err
+		}
+
+		if err := checkParent(tx, 0, tag.ParentID); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&tag).Error; err != nil {
+			return translateError(err)
+		}
+
+		return nil
+
+	}); err != nil {
 		return tag, err
 	}
 
 	return tag, nil
 }
 
-func (tr *tagRepository) ReadAll() ([]entity.Tag, error) {
+func (tr *tagRepository) ReadAll(status entity.NewsStatus) ([]entity.Tag, error) {
+	var tags []entity.Tag
+
+	if err := withNewsCount(tr.db, status).Preload("Translations").Find(&tags).Error; err != nil {
+		return tags, translateError(err)
+	}
+
+	return tags, nil
+}
+
+func (tr *tagRepository) ReadByIDs(ids []uint) ([]entity.Tag, error) {
 	var tags []entity.Tag
 
-	tr.db.Find(&tags)
+	if err := tr.db.Preload("Translations").Where("id IN ?", ids).Find(&tags).Error; err != nil {
+		return tags, translateError(err)
+	}
 
 	return tags, nil
 }
@@ -39,12 +87,35 @@ func (tr *tagRepository) ReadAll() ([]entity.Tag, error) {
 func (tr *tagRepository) Edit(id int, newTag entity.Tag) (entity.Tag, error) {
 	var tag entity.Tag
 
-	if err := tr.db.First(&tag, id).Error; err != nil {
+	if err := tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tag, id).Error; err != nil {
+			return translateError(err)
+		}
+
+		if err := entity.CheckVersion(tag.Version, newTag.Version); err != nil {
+			return err
+		}
+
+		if err := normalizeTag(tx, &newTag, tag.ID); err != nil {
+			return err
+		}
+
+		if err := checkParent(tx, tag.ID, newTag.ParentID); err != nil {
+			return err
+		}
+
+		newTag.Version = tag.Version + 1
+
+		if err := tx.Model(&tag).Select("Name", "NameKey", "Slug", "ParentID", "Version").Updates(newTag).Error; err != nil {
+			return translateError(err)
+		}
+
+		return nil
+
+	}); err != nil {
 		return tag, err
 	}
 
-	tr.db.Model(&tag).Updates(newTag)
-
 	return tag, nil
 }
 
@@ -52,10 +123,418 @@ func (tr *tagRepository) Delete(id int) (entity.Tag, error) {
 	var tag entity.Tag
 
 	if err := tr.db.First(&tag, id).Error; err != nil {
-		return tag, err
+		return tag, translateError(err)
 	}
 
-	tr.db.Delete(&tag)
+	if err := tr.db.Delete(&tag).Error; err != nil {
+		return tag, translateError(err)
+	}
 
 	return tag, nil
 }
+
+func (tr *tagRepository) ReadTrash() ([]entity.Tag, error) {
+	var tags []entity.Tag
+
+	if err := tr.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&tags).Error; err != nil {
+		return tags, translateError(err)
+	}
+
+	return tags, nil
+}
+
+func (tr *tagRepository) Restore(id int) (entity.Tag, error) {
+	var tag entity.Tag
+
+	if err := tr.db.Unscoped().Where("deleted_at IS NOT NULL").First(&tag, id).Error; err != nil {
+		return tag, translateError(err)
+	}
+
+	if err := tr.db.Unscoped().Model(&tag).Update("deleted_at", nil).Error; err != nil {
+		return tag, translateError(err)
+	}
+
+	if err := withNewsCount(tr.db, "").First(&tag, tag.ID).Error; err != nil {
+		return tag, translateError(err)
+	}
+
+	return tag, nil
+}
+
+// Purge permanently deletes a tag, trashed or not, and removes it from news
+func (tr *tagRepository) Purge(id int) (entity.Tag, error) {
+	var tag entity.Tag
+
+	if err := tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().First(&tag, id).Error; err != nil {
+			return translateError(err)
+		}
+
+		return purgeTags(tx, []uint{tag.ID})
+
+	}); err != nil {
+		return tag, err
+	}
+
+	return tag, nil
+}
+
+// PurgeTrash permanently deletes the tags trashed before the given time
+func (tr *tagRepository) PurgeTrash(before time.Time) (int64, error) {
+	var ids []uint
+
+	if err := tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&entity.Tag{}).Where("deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+			return translateError(err)
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		return purgeTags(tx, ids)
+
+	}); err != nil {
+		return 0, err
+	}
+
+	return int64(len(ids)), nil
+}
+
+// Merge moves the news of the source tags to the target tag, without
+// tagging a news twice, and trashes the source tags. The target is renamed
+// when newName is given.
+func (tr *tagRepository) Merge(targetID int, sourceIDs []int, newName string) (entity.Tag, error) {
+	var target entity.Tag
+
+	if err := tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&target, targetID).Error; err != nil {
+			return translateError(err)
+		}
+
+		sources := []int{}
+		seen := map[int]bool{}
+
+		for _, id := range sourceIDs {
+			if id == targetID {
+				return entity.ValidationError{Fields: []entity.FieldError{{
+					Field:   "source_ids",
+					Message: "source_ids must not contain the target tag",
+				}}}
+			}
+
+			if !seen[id] {
+				seen[id] = true
+				sources = append(sources, id)
+			}
+		}
+
+		var found []int
+
+		if err := tx.Model(&entity.Tag{}).Where("id IN ?", sources).Pluck("id", &found).Error; err != nil {
+			return translateError(err)
+		}
+
+		if len(found) != len(sources) {
+			unknown := entity.UnknownTagError{}
+
+			for _, id := range sources {
+				if !containsInt(found, id) {
+					unknown.IDs = append(unknown.IDs, id)
+				}
+			}
+
+			return unknown
+		}
+
+		var sourceNewsIDs []uint
+
+		if err := tx.Model(&entity.NewsTags{}).Distinct("news_id").Where("tag_id IN ?", sources).Pluck("news_id", &sourceNewsIDs).Error; err != nil {
+			return translateError(err)
+		}
+
+		var targetNewsIDs []uint
+
+		if err := tx.Model(&entity.NewsTags{}).Where("tag_id = ?", target.ID).Pluck("news_id", &targetNewsIDs).Error; err != nil {
+			return translateError(err)
+		}
+
+		tagged := map[uint]bool{}
+		for _, newsID := range targetNewsIDs {
+			tagged[newsID] = true
+		}
+
+		for _, newsID := range sourceNewsIDs {
+			if tagged[newsID] {
+				continue
+			}
+
+			if err := tx.Create(&entity.NewsTags{NewsID: newsID, TagID: target.ID}).Error; err != nil {
+				return translateError(err)
+			}
+		}
+
+		if err := tx.Where("tag_id IN ?", sources).Delete(&entity.NewsTags{}).Error; err != nil {
+			return translateError(err)
+		}
+
+		if err := reparentMergedChildren(tx, &target, sources); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&entity.Tag{}, sources).Error; err != nil {
+			return translateError(err)
+		}
+
+		updates := entity.Tag{Version: target.Version + 1}
+
+		if newName != "" {
+			updates.Name = newName
+
+			// the target may take the name of a tag merged into it
+			merged := []uint{}
+			for _, id := range sources {
+				merged = append(merged, uint(id))
+			}
+
+			if err := normalizeTag(tx, &updates, append(merged, target.ID)...); err != nil {
+				return err
+			}
+
+			if err := freeTagName(tx, updates, merged); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&target).Updates(updates).Error; err != nil {
+			return translateError(err)
+		}
+
+		return translateError(withNewsCount(tx, "").First(&target, target.ID).Error)
+
+	}); err != nil {
+		return target, err
+	}
+
+	return target, nil
+}
+
+// SaveTranslation adds the translation of a tag name to a locale, or
+// replaces the one it already has
+func (tr *tagRepository) SaveTranslation(id int, translation entity.TagTranslation) (entity.TagTranslation, error) {
+	if err := tr.db.Transaction(func(tx *gorm.DB) error {
+		var tag entity.Tag
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tag, id).Error; err != nil {
+			return translateError(err)
+		}
+
+		translation.Name = utils.NormalizeName(translation.Name)
+
+		if translation.Name == "" {
+			return entity.ValidationError{Fields: []entity.FieldError{{
+				Field:   "name",
+				Message: "name must not be blank",
+			}}}
+		}
+
+		var existing entity.TagTranslation
+
+		err := tx.Where("tag_id = ? AND locale = ?", tag.ID, translation.Locale).First(&existing).Error
+		if err := translateError(err); err != nil && err != entity.ErrNotFound {
+			return err
+		}
+
+		translation.ID = existing.ID
+		translation.TagID = tag.ID
+		translation.CreatedAt = existing.CreatedAt
+
+		return translateError(tx.Save(&translation).Error)
+
+	}); err != nil {
+		return translation, err
+	}
+
+	return translation, nil
+}
+
+// reparentMergedChildren moves the children of merged tags under the target.
+// When the target itself sits below a merged tag, it moves up to take its place.
+func reparentMergedChildren(tx *gorm.DB, target *entity.Tag, sources []int) error {
+	var tags []entity.Tag
+
+	if err := tx.Select("id", "parent_id").Find(&tags).Error; err != nil {
+		return translateError(err)
+	}
+
+	parents := map[uint]*uint{}
+	for _, tag := range tags {
+		parents[tag.ID] = tag.ParentID
+	}
+
+	// climb above the highest merged ancestor, so no cycle is left behind
+	targetParent := target.ParentID
+	seen := map[uint]bool{}
+
+	for ancestor := target.ParentID; ancestor != nil && !seen[*ancestor]; ancestor = parents[*ancestor] {
+		seen[*ancestor] = true
+
+		if containsInt(sources, int(*ancestor)) {
+			targetParent = parents[*ancestor]
+		}
+	}
+
+	if err := tx.Model(target).Update("parent_id", targetParent).Error; err != nil {
+		return translateError(err)
+	}
+
+	if err := tx.Model(&entity.Tag{}).
+		Where("parent_id IN ? AND id <> ?", sources, target.ID).
+		Update("parent_id", target.ID).Error; err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// freeTagName purges the merged tags holding the name or slug the target of
+// the merge takes, the trash would otherwise keep them taken
+func freeTagName(tx *gorm.DB, tag entity.Tag, merged []uint) error {
+	var taken []uint
+
+	if err := tx.Unscoped().Model(&entity.Tag{}).
+		Where("id IN ? AND (slug = ? OR name_key = ?)", merged, tag.Slug, tag.NameKey).
+		Pluck("id", &taken).Error; err != nil {
+		return translateError(err)
+	}
+
+	if len(taken) == 0 {
+		return nil
+	}
+
+	return purgeTags(tx, taken)
+}
+
+// normalizeTag cleans up the tag name and makes sure no other tag, including
+// trashed ones, has the same name regardless of case. The tag gets the slug of
+// its name, with a number when another tag has it.
+func normalizeTag(tx *gorm.DB, tag *entity.Tag, excludeIDs ...uint) error {
+	tag.Name = utils.NormalizeName(tag.Name)
+	tag.NameKey = utils.NameKey(tag.Name)
+
+	if utils.Slugify(tag.Name) == "" {
+		return entity.ValidationError{Fields: []entity.FieldError{{
+			Field:   "name",
+			Message: "name must contain a letter or a digit",
+		}}}
+	}
+
+	var existing entity.Tag
+
+	err := tx.Unscoped().
+		Where("name_key = ? AND id NOT IN ?", tag.NameKey, excludeIDs).
+		First(&existing).Error
+
+	if err == nil {
+		if existing.DeletedAt.Valid {
+			return fmt.Errorf("%w: tag %q already exists in the trash", entity.ErrConflict, existing.Name)
+		}
+
+		return fmt.Errorf("%w: tag %q already exists", entity.ErrConflict, existing.Name)
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return translateError(err)
+	}
+
+	tag.Slug, err = utils.UniqueSlug(tag.Name, "tag", func(slug string) (bool, error) {
+		var count int64
+
+		if err := tx.Unscoped().Model(&entity.Tag{}).Where("slug = ? AND id NOT IN ?", slug, excludeIDs).Count(&count).Error; err != nil {
+			return false, translateError(err)
+		}
+
+		return count > 0, nil
+	})
+
+	return err
+}
+
+// checkParent makes sure the parent exists and is not the tag itself or one
+// of its descendants, which would make a cycle
+func checkParent(tx *gorm.DB, tagID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+
+	invalid := func(message string) error {
+		return entity.ValidationError{Fields: []entity.FieldError{{
+			Field:   "parent_id",
+			Message: message,
+		}}}
+	}
+
+	seen := map[uint]bool{}
+
+	for id := *parentID; ; {
+		if id == tagID {
+			return invalid("parent_id would make the tag its own ancestor")
+		}
+
+		if seen[id] {
+			return nil
+		}
+		seen[id] = true
+
+		var parent entity.Tag
+
+		err := tx.Select("id", "parent_id").First(&parent, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if id == *parentID {
+				return invalid("parent tag does not exist")
+			}
+
+			return nil
+		}
+
+		if err != nil {
+			return translateError(err)
+		}
+
+		if parent.ParentID == nil {
+			return nil
+		}
+
+		id = *parent.ParentID
+	}
+}
+
+func purgeTags(tx *gorm.DB, ids []uint) error {
+	if err := tx.Where("tag_id IN ?", ids).Delete(&entity.NewsTags{}).Error; err != nil {
+		return translateError(err)
+	}
+
+	if err := tx.Where("tag_id IN ?", ids).Delete(&entity.TagTranslation{}).Error; err != nil {
+		return translateError(err)
+	}
+
+	if err := tx.Unscoped().Model(&entity.Tag{}).Where("parent_id IN ?", ids).Update("parent_id", nil).Error; err != nil {
+		return translateError(err)
+	}
+
+	if err := tx.Unscoped().Delete(&entity.Tag{}, ids).Error; err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
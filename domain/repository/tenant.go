@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+type TenantInterface interface {
+	Create(tenant entity.Tenant) (entity.Tenant, error)
+	ReadAll() ([]entity.Tenant, error)
+	ReadOne(id int) (entity.Tenant, error)
+	ReadBySlug(slug string) (entity.Tenant, error)
+	ReadByHost(host string) (entity.Tenant, error)
+}
+
+type tenantRepository struct {
+	db *gorm.DB
+}
+
+func NewTenantRepository(db *gorm.DB) *tenantRepository {
+	return &tenantRepository{db: db}
+}
+
+func (tr *tenantRepository) Create(tenant entity.Tenant) (entity.Tenant, error) {
+	tenant.Slug = strings.ToLower(strings.TrimSpace(tenant.Slug))
+	tenant.Host = strings.ToLower(strings.TrimSpace(tenant.Host))
+
+	if err := tr.db.Create(&tenant).Error; err != nil {
+		return tenant, translateError(err)
+	}
+
+	return tenant, nil
+}
+
+func (tr *tenantRepository) ReadAll() ([]entity.Tenant, error) {
+	var tenants []entity.Tenant
+
+	if err := tr.db.Order("id").Find(&tenants).Error; err != nil {
+		return tenants, translateError(err)
+	}
+
+	return tenants, nil
+}
+
+func (tr *tenantRepository) ReadOne(id int) (entity.Tenant, error) {
+	var tenant entity.Tenant
+
+	if err := tr.db.First(&tenant, id).Error; err != nil {
+		return tenant, translateError(err)
+	}
+
+	return tenant, nil
+}
+
+func (tr *tenantRepository) ReadBySlug(slug string) (entity.Tenant, error) {
+	var tenant entity.Tenant
+
+	if err := tr.db.Where("slug = ?", strings.ToLower(strings.TrimSpace(slug))).First(&tenant).Error; err != nil {
+		return tenant, translateError(err)
+	}
+
+	return tenant, nil
+}
+
+func (tr *tenantRepository) ReadByHost(host string) (entity.Tenant, error) {
+	var tenant entity.Tenant
+
+	if err := tr.db.Where("host = ?", strings.ToLower(host)).First(&tenant).Error; err != nil {
+		return tenant, translateError(err)
+	}
+
+	return tenant, nil
+}
+
+// tenantKey holds the tenant in the context of a tenant-scoped database
+type tenantKey struct{}
+
+// forTenant returns a database only reading and writing the rows of a tenant.
+// Tenant 0 is no tenant at all, used by background jobs working on every tenant.
+func forTenant(db *gorm.DB, tenantID uint) *gorm.DB {
+	return db.WithContext(context.WithValue(context.Background(), tenantKey{}, tenantID))
+}
+
+// registerTenantScope adds callbacks filtering every query on a model with a
+// TenantID by the tenant of the database, and setting it on created rows.
+// It is safe to call more than once on the same database.
+func registerTenantScope(db *gorm.DB) {
+	if db.Callback().Query().Get("tenant:query") != nil {
+		return
+	}
+
+	db.Callback().Create().Before("gorm:create").Register("tenant:create", setTenant)
+	db.Callback().Query().Before("gorm:query").Register("tenant:query", whereTenant)
+	db.Callback().Row().Before("gorm:row").Register("tenant:row", whereTenant)
+	db.Callback().Update().Before("gorm:update").Register("tenant:update", whereTenant)
+	db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", whereTenant)
+}
+
+// scopedTenant returns the tenant of the database and the tenant field of
+// the model, when both exist
+func scopedTenant(db *gorm.DB) (uint, *schema.Field, bool) {
+	tenantID, _ := db.Statement.Context.Value(tenantKey{}).(uint)
+	if tenantID == 0 || db.Statement.Schema == nil {
+		return 0, nil, false
+	}
+
+	field := db.Statement.Schema.LookUpField("TenantID")
+
+	return tenantID, field, field != nil
+}
+
+func whereTenant(db *gorm.DB) {
+	tenantID, field, ok := scopedTenant(db)
+	if !ok {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID},
+	}})
+}
+
+func setTenant(db *gorm.DB) {
+	tenantID, field, ok := scopedTenant(db)
+	if !ok {
+		return
+	}
+
+	value := db.Statement.ReflectValue
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			db.AddError(field.Set(db.Statement.Context, reflect.Indirect(value.Index(i)), tenantID))
+		}
+	case reflect.Struct:
+		db.AddError(field.Set(db.Statement.Context, value, tenantID))
+	}
+}
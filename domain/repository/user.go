@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"gorm.io/gorm"
+)
+
+type UserInterface interface {
+	ForTenant(tenantID uint) UserInterface
+	Create(user entity.User) (entity.User, error)
+	ReadOne(id int) (entity.User, error)
+	ReadByUsername(username string) (entity.User, error)
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) *userRepository {
+	registerTenantScope(db)
+
+	return &userRepository{db: db}
+}
+
+// ForTenant returns a repository only seeing the users of one tenant
+func (ur *userRepository) ForTenant(tenantID uint) UserInterface {
+	return &userRepository{db: forTenant(ur.db, tenantID)}
+}
+
+// Create stores a user, its password must already be hashed
+func (ur *userRepository) Create(user entity.User) (entity.User, error) {
+	user.Username = strings.ToLower(strings.TrimSpace(user.Username))
+
+	if err := ur.db.Create(&user).Error; err != nil {
+		return user, translateError(err)
+	}
+
+	return user, nil
+}
+
+func (ur *userRepository) ReadOne(id int) (entity.User, error) {
+	var user entity.User
+
+	if err := ur.db.First(&user, id).Error; err != nil {
+		return user, translateError(err)
+	}
+
+	return user, nil
+}
+
+func (ur *userRepository) ReadByUsername(username string) (entity.User, error) {
+	var user entity.User
+
+	if err := ur.db.Where("username = ?", strings.ToLower(strings.TrimSpace(username))).First(&user).Error; err != nil {
+		return user, translateError(err)
+	}
+
+	return user, nil
+}
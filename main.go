@@ -1,18 +1,25 @@
 package main
 
 import (
+	"os"
+
 	config "github.com/furqonzt99/news-redis/configs"
 	"github.com/furqonzt99/news-redis/constants"
 	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/furqonzt99/news-redis/delivery/controllers/apikeys"
+	"github.com/furqonzt99/news-redis/delivery/controllers/auth"
 	"github.com/furqonzt99/news-redis/delivery/controllers/news"
 	"github.com/furqonzt99/news-redis/delivery/controllers/tags"
 	"github.com/furqonzt99/news-redis/delivery/middlewares"
 	"github.com/furqonzt99/news-redis/delivery/routes"
+	"github.com/furqonzt99/news-redis/domain/entity"
 	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/furqonzt99/news-redis/services"
 	"github.com/furqonzt99/news-redis/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/labstack/gommon/log"
 )
 
 func main() {
@@ -24,6 +31,14 @@ func main() {
 
 	constants.Rdb = utils.InitRedis(config)
 
+	if config.Auth.Secret == "" {
+		log.Fatal("JWT_SECRET must be set")
+	}
+
+	services.ConfigureAuth(config.Auth.Secret, config.Auth.AccessTokenTTL, config.Auth.RefreshTokenTTL)
+
+	common.ConfigureLocales(config.Locale.Default, config.Locale.Supported)
+
 	e := echo.New()
 
 	// CORS
@@ -38,17 +53,160 @@ func main() {
 	// validator
 	e.Validator = &common.Validator{Validator: validator.New()}
 
+	// error handler
+	e.HTTPErrorHandler = common.ErrorHandler
+
 	// repository
 	tr := repository.NewTagRepository(db)
 	nr := repository.NewNewsRepository(db)
+	ur := repository.NewUserRepository(db)
+	kr := repository.NewAPIKeyRepository(db)
+	tnr := repository.NewTenantRepository(db)
+
+	// commands, e.g. go run . rebuild-popular-tags
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1:], nr, ur, tnr)
+		return
+	}
+
+	// tenant, from the X-Tenant header or the host
+	middlewares.TenantMiddleware(e, tnr)
 
 	// controller
 	tc := tags.NewTagController(tr)
 	nc := news.NewNewsController(nr)
+	ac := auth.NewAuthController(ur)
+	kc := apikeys.NewAPIKeyController(kr)
+
+	// authentication, with a JWT access token or an API key, behind a limit
+	// by IP so that guessed tokens and keys are counted too
+	authRateLimit := middlewares.RateLimitMiddleware(middlewares.KeyByIP, middlewares.RateLimitRule{
+		Group:  "auth",
+		Limit:  config.RateLimit.AuthLimit,
+		Window: config.RateLimit.AuthWindow,
+	})
+	authMiddleware := chain(authRateLimit, middlewares.AuthMiddleware(kr))
+	optionalAuthMiddleware := chain(authRateLimit, middlewares.OptionalAuthMiddleware(kr))
+
+	// rate limits, each group of routes has its own limits and windows
+	rateLimitKey := middlewares.ClientKey(config.RateLimit.Key)
+	rateLimit := func(group string) echo.MiddlewareFunc {
+		rates := config.RateLimit.Groups[group]
+
+		return middlewares.RateLimitMiddleware(rateLimitKey,
+			middlewares.RateLimitRule{
+				Group:  group + ":read",
+				Limit:  rates.ReadLimit,
+				Window: rates.ReadWindow,
+				Match:  middlewares.ReadRequest,
+			},
+			middlewares.RateLimitRule{
+				Group:  group + ":write",
+				Limit:  rates.WriteLimit,
+				Window: rates.WriteWindow,
+				Match:  middlewares.WriteRequest,
+			},
+		)
+	}
+	loginRateLimit := middlewares.RateLimitMiddleware(middlewares.KeyByIP, middlewares.RateLimitRule{
+		Group:  "login",
+		Limit:  config.RateLimit.LoginLimit,
+		Window: config.RateLimit.LoginWindow,
+	})
 
 	// routes
-	routes.RegisterTagPath(e, tc)
-	routes.RegisterNewsPath(e, nc)
+	routes.RegisterAuthPath(e, ac, loginRateLimit)
+	routes.RegisterAPIKeyPath(e, kc, authMiddleware, rateLimit("apikeys"))
+	routes.RegisterTagPath(e, tc, authMiddleware, optionalAuthMiddleware, rateLimit("tags"))
+	routes.RegisterNewsPath(e, nc, authMiddleware, optionalAuthMiddleware, rateLimit("news"))
+
+	// background jobs
+	services.StartScheduler(nr, config.Scheduler.Interval)
+	services.StartTrashRetention(nr, tr, config.Trash.Retention, config.Trash.PurgeInterval)
+	services.ViewDedupWindow = config.Views.DedupWindow
+	services.StartViewFlush(nr, config.Views.FlushInterval)
 
 	e.Logger.Fatal(e.Start(":" + config.Port))
 }
+
+// chain runs middlewares one after the other, as a single one
+func chain(middlewares ...echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+
+		return next
+	}
+}
+
+func runCommand(args []string, nr repository.NewsInterface, ur repository.UserInterface, tnr repository.TenantInterface) {
+	switch args[0] {
+	case "rebuild-popular-tags":
+		tenants, err := tnr.ReadAll()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, tenant := range tenants {
+			if err := services.RebuildTagPopularity(tenant.ID, nr); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		log.Info("popular tags rebuilt")
+	case "create-tenant":
+		if len(args) != 3 && len(args) != 4 {
+			log.Fatal("usage: create-tenant <slug> <name> [host]")
+		}
+
+		tenant := entity.Tenant{Slug: args[1], Name: args[2]}
+		if len(args) == 4 {
+			tenant.Host = args[3]
+		}
+
+		tenant, err := tnr.Create(tenant)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Infof("tenant %s created with id %d", tenant.Slug, tenant.ID)
+	case "create-user":
+		if len(args) < 3 || len(args) > 5 {
+			log.Fatal("usage: create-user <username> <password> [writer|editor|admin] [tenant]")
+		}
+
+		role := entity.RoleWriter
+		if len(args) >= 4 {
+			role = entity.Role(args[3])
+		}
+
+		tenantID := entity.DefaultTenantID
+		if len(args) == 5 {
+			tenant, err := tnr.ReadBySlug(args[4])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			tenantID = tenant.ID
+		}
+
+		if !role.Valid() {
+			log.Fatalf("unknown role %s", role)
+		}
+
+		password, err := utils.HashPassword(args[2])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		user, err := ur.ForTenant(tenantID).Create(entity.User{Username: args[1], Password: password, Role: role})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Infof("%s %s created", user.Role, user.Username)
+	default:
+		log.Fatalf("unknown command %s", args[0])
+	}
+}
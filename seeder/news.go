@@ -1,6 +1,7 @@
 package seeder
 
 import (
+	"fmt"
 	"math/rand"
 
 	"github.com/furqonzt99/news-redis/domain/entity"
@@ -8,10 +9,11 @@ import (
 )
 
 func NewsSeeder(db *gorm.DB) {
-	status := []string{"draft", "publish", "deleted"}
+	status := []entity.NewsStatus{entity.StatusDraft, entity.StatusPublish, entity.StatusDeleted}
 	for i := 1; i <= 100; i++ {
 		db.Create(&entity.News{
 			Title:  "Title",
+			Slug:   "title-" + fmt.Sprint(i),
 			Body:   "Body",
 			Status: status[rand.Intn(3-0)],
 		})
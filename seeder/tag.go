@@ -10,7 +10,9 @@ import (
 func TagSeeder(db *gorm.DB) {
 	for i := 1; i <= 10; i++ {
 		db.Create(&entity.Tag{
-			Name: "Topic" + fmt.Sprint(i),
+			Name:    "Topic" + fmt.Sprint(i),
+			NameKey: "topic" + fmt.Sprint(i),
+			Slug:    "topic" + fmt.Sprint(i),
 		})
 	}
 }
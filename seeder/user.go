@@ -0,0 +1,20 @@
+package seeder
+
+import (
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/utils"
+	"gorm.io/gorm"
+)
+
+// UserSeeder creates one user per role, named after the role, with the password "password"
+func UserSeeder(db *gorm.DB) {
+	password, _ := utils.HashPassword("password")
+
+	for _, role := range []entity.Role{entity.RoleAdmin, entity.RoleEditor, entity.RoleWriter} {
+		db.Create(&entity.User{
+			Username: string(role),
+			Password: password,
+			Role:     role,
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const apiKeyPrefix = "nr_"
+
+// GenerateAPIKey returns a new random API key, to be shown to its owner once
+func GenerateAPIKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return apiKeyPrefix + hex.EncodeToString(secret), nil
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up by. Keys are
+// long and random, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// APIKeyPrefix returns the start of a key, stored to help telling keys apart
+func APIKeyPrefix(key string) string {
+	if len(key) < 10 {
+		return key
+	}
+
+	return key[:10]
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/furqonzt99/news-redis/constants"
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+
+	revokedTokenPrefix = "revoked:token:"
+)
+
+var (
+	tokenSecret     []byte
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// ConfigureAuth sets the key signing tokens and how long tokens are valid
+func ConfigureAuth(secret string, accessTTL, refreshTTL time.Duration) {
+	tokenSecret = []byte(secret)
+	accessTokenTTL = accessTTL
+	refreshTokenTTL = refreshTTL
+}
+
+type TokenClaims struct {
+	jwt.StandardClaims
+	TenantID uint        `json:"tenant_id"`
+	Username string      `json:"username"`
+	Role     entity.Role `json:"role"`
+	Type     string      `json:"type"`
+}
+
+// UserID returns the id of the user the token was issued to
+func (c TokenClaims) UserID() uint {
+	var id uint
+	fmt.Sscan(c.Subject, &id)
+
+	return id
+}
+
+// Tenant returns the tenant of the user the token was issued to, tokens
+// issued before users had a tenant belong to the default one
+func (c TokenClaims) Tenant() uint {
+	if c.TenantID == 0 {
+		return entity.DefaultTenantID
+	}
+
+	return c.TenantID
+}
+
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
+
+// IssueTokens signs a new access and refresh token for a user
+func IssueTokens(user entity.User) (TokenPair, error) {
+	now := time.Now()
+
+	accessToken, err := signToken(user, AccessToken, now, accessTokenTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refreshToken, err := signToken(user, RefreshToken, now, refreshTokenTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    accessTokenTTL,
+	}, nil
+}
+
+func signToken(user entity.User, tokenType string, now time.Time, ttl time.Duration) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	claims := TokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        hex.EncodeToString(id),
+			Subject:   fmt.Sprint(user.ID),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+		},
+		TenantID: user.TenantID,
+		Username: user.Username,
+		Role:     user.Role,
+		Type:     tokenType,
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(tokenSecret)
+}
+
+// ParseToken verifies a token of the given type and that it was not revoked
+func ParseToken(token string, tokenType string) (TokenClaims, error) {
+	var claims TokenClaims
+
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+
+		return tokenSecret, nil
+	})
+	if err != nil {
+		return claims, fmt.Errorf("%w: invalid token", entity.ErrUnauthorized)
+	}
+
+	if claims.Type != tokenType || claims.Id == "" {
+		return claims, fmt.Errorf("%w: invalid token", entity.ErrUnauthorized)
+	}
+
+	revoked, err := constants.Rdb.Exists(ctx, revokedTokenPrefix+claims.Id).Result()
+	if err != nil {
+		return claims, err
+	}
+
+	if revoked > 0 {
+		return claims, fmt.Errorf("%w: token has been revoked", entity.ErrUnauthorized)
+	}
+
+	return claims, nil
+}
+
+// RevokeToken rejects a token from now on and reports whether this call
+// revoked it, so a refresh token is only exchanged once. The revocation is
+// kept until the token expires anyway.
+func RevokeToken(claims TokenClaims) (bool, error) {
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if ttl <= 0 {
+		return false, nil
+	}
+
+	return constants.Rdb.SetNX(ctx, revokedTokenPrefix+claims.Id, claims.Subject, ttl).Result()
+}
@@ -9,8 +9,20 @@ import (
 
 var ctx = context.Background()
 
-func CreateCache(entity string, id int, filter interface{}, data interface{}) error {
-	key := entity + ":" + fmt.Sprint(id) + ":" + fmt.Sprint(filter)
+// cachePrefix starts every cache key. Keys go on with the tenant, the entity,
+// the id and the filter, so a tenant only ever reads and deletes its own cache.
+const cachePrefix = "tenant:"
+
+// AllTenants makes DeleteCache delete the cache of every tenant, for jobs
+// changing the news of several tenants at once
+const AllTenants uint = 0
+
+func cacheEntity(tenantID uint, entity string) string {
+	return cachePrefix + fmt.Sprint(tenantID) + ":" + entity
+}
+
+func CreateCache(tenantID uint, entity string, id int, filter interface{}, data interface{}) error {
+	key := cacheEntity(tenantID, entity) + ":" + fmt.Sprint(id) + ":" + fmt.Sprint(filter)
 
 	err := constants.Rdb.Set(ctx, key, data, 0).Err()
 	if err != nil {
@@ -20,8 +32,8 @@ func CreateCache(entity string, id int, filter interface{}, data interface{}) er
 	return nil
 }
 
-func GetCache(entity string, id int, filter interface{}) (string, error) {
-	key := entity + ":" + fmt.Sprint(id) + ":" + fmt.Sprint(filter)
+func GetCache(tenantID uint, entity string, id int, filter interface{}) (string, error) {
+	key := cacheEntity(tenantID, entity) + ":" + fmt.Sprint(id) + ":" + fmt.Sprint(filter)
 
 	data, err := constants.Rdb.Get(ctx, key).Result()
 	if err != nil {
@@ -31,8 +43,13 @@ func GetCache(entity string, id int, filter interface{}) (string, error) {
 	return data, nil
 }
 
-func DeleteCache(entity string) error {
-	iter := constants.Rdb.Scan(ctx, 0, entity+"*", 0).Iterator()
+func DeleteCache(tenantID uint, entity string) error {
+	pattern := cacheEntity(tenantID, entity) + ":*"
+	if tenantID == AllTenants {
+		pattern = cachePrefix + "*:" + entity + ":*"
+	}
+
+	iter := constants.Rdb.Scan(ctx, 0, pattern, 0).Iterator()
 	for iter.Next(ctx) {
 		err := constants.Rdb.Del(ctx, iter.Val()).Err()
 		if err != nil {
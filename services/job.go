@@ -0,0 +1,33 @@
+package services
+
+import (
+	"time"
+
+	"github.com/furqonzt99/news-redis/constants"
+	"github.com/labstack/gommon/log"
+)
+
+// RunJob runs job every interval in the background. A Redis lock makes sure
+// only one API instance runs a given job per interval.
+func RunJob(name string, interval time.Duration, job func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			locked, err := constants.Rdb.SetNX(ctx, "lock:job:"+name, time.Now().String(), interval).Result()
+			if err != nil {
+				log.Errorf("job %s: %v", name, err)
+				continue
+			}
+
+			if !locked {
+				continue
+			}
+
+			if err := job(); err != nil {
+				log.Errorf("job %s: %v", name, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/furqonzt99/news-redis/constants"
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/go-redis/redis/v8"
+)
+
+// popularTagsPrefix is followed by the tenant, each key is a sorted set of
+// the tag ids of the tenant scored by their number of news
+const popularTagsPrefix = "popular:tag:"
+
+type TagPopularity struct {
+	TagID uint
+	Count int64
+}
+
+// SetTagPopularity stores the news count of tags, tags without news are removed
+func SetTagPopularity(tenantID uint, counts map[uint]int64) error {
+	key := popularTagsKey(tenantID)
+
+	pipe := constants.Rdb.TxPipeline()
+
+	for tagID, count := range counts {
+		if count > 0 {
+			pipe.ZAdd(ctx, key, &redis.Z{Score: float64(count), Member: tagID})
+		} else {
+			pipe.ZRem(ctx, key, tagID)
+		}
+	}
+
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+func RemoveTagPopularity(tenantID uint, tagIDs []uint) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	members := []interface{}{}
+	for _, tagID := range tagIDs {
+		members = append(members, tagID)
+	}
+
+	return constants.Rdb.ZRem(ctx, popularTagsKey(tenantID), members...).Err()
+}
+
+// ReplaceTagPopularity rebuilds the sorted set from scratch
+func ReplaceTagPopularity(tenantID uint, counts map[uint]int64) error {
+	key := popularTagsKey(tenantID)
+
+	pipe := constants.Rdb.TxPipeline()
+
+	pipe.Del(ctx, key)
+
+	for tagID, count := range counts {
+		if count > 0 {
+			pipe.ZAdd(ctx, key, &redis.Z{Score: float64(count), Member: tagID})
+		}
+	}
+
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+func ReadPopularTags(tenantID uint, limit int64) ([]TagPopularity, error) {
+	members, err := constants.Rdb.ZRevRangeWithScores(ctx, popularTagsKey(tenantID), 0, limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	popularity := []TagPopularity{}
+
+	for _, member := range members {
+		tagID, err := strconv.ParseUint(fmt.Sprint(member.Member), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		popularity = append(popularity, TagPopularity{TagID: uint(tagID), Count: int64(member.Score)})
+	}
+
+	return popularity, nil
+}
+
+// RefreshTagPopularity recounts the news of the given tags of a tenant
+func RefreshTagPopularity(tenantID uint, newsRepository repository.NewsInterface, tagIDs []uint) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	counts, err := newsRepository.ForTenant(tenantID).CountByTags(tagIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, tagID := range tagIDs {
+		if _, ok := counts[tagID]; !ok {
+			counts[tagID] = 0
+		}
+	}
+
+	return SetTagPopularity(tenantID, counts)
+}
+
+// RebuildTagPopularity recounts the news of every tag of a tenant from news_tags
+func RebuildTagPopularity(tenantID uint, newsRepository repository.NewsInterface) error {
+	counts, err := newsRepository.ForTenant(tenantID).CountByTags(nil)
+	if err != nil {
+		return err
+	}
+
+	return ReplaceTagPopularity(tenantID, counts)
+}
+
+func popularTagsKey(tenantID uint) string {
+	return popularTagsPrefix + fmt.Sprint(tenantID)
+}
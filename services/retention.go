@@ -0,0 +1,42 @@
+package services
+
+import (
+	"time"
+
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/labstack/gommon/log"
+)
+
+// StartTrashRetention permanently deletes news and tags that have been in
+// the trash for longer than retention
+func StartTrashRetention(newsRepository repository.NewsInterface, tagRepository repository.TagInterface, retention time.Duration, interval time.Duration) {
+	RunJob("trash-retention", interval, func() error {
+		return PurgeTrash(newsRepository, tagRepository, time.Now().Add(-retention))
+	})
+}
+
+func PurgeTrash(newsRepository repository.NewsInterface, tagRepository repository.TagInterface, before time.Time) error {
+	purgedNews, err := newsRepository.PurgeTrash(before)
+	if err != nil {
+		return err
+	}
+
+	purgedTags, err := tagRepository.PurgeTrash(before)
+	if err != nil {
+		return err
+	}
+
+	if purgedNews > 0 || purgedTags > 0 {
+		log.Infof("trash retention: purged %d news and %d tags", purgedNews, purgedTags)
+	}
+
+	if purgedTags > 0 {
+		if err := DeleteCache(AllTenants, "tag"); err != nil {
+			return err
+		}
+
+		return DeleteCache(AllTenants, "news")
+	}
+
+	return nil
+}
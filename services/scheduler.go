@@ -0,0 +1,73 @@
+package services
+
+import (
+	"time"
+
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/labstack/gommon/log"
+)
+
+const schedulerEditor = "scheduler"
+
+// StartScheduler publishes and unpublishes news when their publish_at and
+// unpublish_at times are reached
+func StartScheduler(newsRepository repository.NewsInterface, interval time.Duration) {
+	RunJob("scheduler", interval, func() error {
+		return RunSchedule(newsRepository, time.Now())
+	})
+}
+
+func RunSchedule(newsRepository repository.NewsInterface, now time.Time) error {
+	// the tenants whose news changed status, their tags are recounted
+	changed := map[uint]bool{}
+
+	duePublish, err := newsRepository.ReadDuePublish(now)
+	if err != nil {
+		return err
+	}
+
+	for _, news := range duePublish {
+		published, err := newsRepository.PublishScheduled(news, schedulerEditor)
+		if err != nil {
+			log.Warnf("scheduler: publish news %d: %v", news.ID, err)
+			continue
+		}
+
+		if published {
+			changed[news.TenantID] = true
+		}
+	}
+
+	dueUnpublish, err := newsRepository.ReadDueUnpublish(now)
+	if err != nil {
+		return err
+	}
+
+	for _, news := range dueUnpublish {
+		unpublished, err := newsRepository.UnpublishScheduled(news, schedulerEditor)
+		if err != nil {
+			log.Warnf("scheduler: unpublish news %d: %v", news.ID, err)
+			continue
+		}
+
+		if unpublished {
+			changed[news.TenantID] = true
+		}
+	}
+
+	for tenantID := range changed {
+		if err := RebuildTagPopularity(tenantID, newsRepository); err != nil {
+			log.Warnf("scheduler: recount tags of tenant %d: %v", tenantID, err)
+		}
+	}
+
+	if len(changed) > 0 {
+		if err := DeleteCache(AllTenants, "tag"); err != nil {
+			return err
+		}
+
+		return DeleteCache(AllTenants, "news")
+	}
+
+	return nil
+}
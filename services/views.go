@@ -0,0 +1,165 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/furqonzt99/news-redis/constants"
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// viewsPendingKey is a hash of news id to the views not flushed to the database yet
+	viewsPendingKey = "views:pending"
+	// viewsSeenPrefix marks a client as having viewed a news during the dedup window
+	viewsSeenPrefix = "views:seen:"
+	// viewsHourPrefix is followed by the tenant and the hour, each key is a
+	// sorted set of the news ids of the tenant scored by their views that hour
+	viewsHourPrefix = "views:hour:"
+	// viewsTrendingPrefix caches the union of the hourly sets of a tenant for a window
+	viewsTrendingPrefix = "views:trending:"
+)
+
+// MaxTrendingWindow is how long the hourly view sets are kept
+const MaxTrendingWindow = 7 * 24 * time.Hour
+
+// ViewDedupWindow is how long views from the same client count once
+var ViewDedupWindow = 30 * time.Minute
+
+// flushViews subtracts the flushed views from the pending ones, removing the
+// news left without pending views
+var flushViews = redis.NewScript(`
+for i = 1, #ARGV, 2 do
+	if redis.call("HINCRBY", KEYS[1], ARGV[i], -tonumber(ARGV[i + 1])) <= 0 then
+		redis.call("HDEL", KEYS[1], ARGV[i])
+	end
+end
+return 0
+`)
+
+type NewsViews struct {
+	NewsID uint
+	Views  int64
+}
+
+// CountView counts a view of a news of a tenant, unless the client already
+// viewed it during the dedup window
+func CountView(tenantID uint, newsID int, client string) error {
+	now := time.Now()
+
+	hash := sha1.Sum([]byte(client))
+	seenKey := viewsSeenPrefix + fmt.Sprint(newsID) + ":" + hex.EncodeToString(hash[:])
+
+	first, err := constants.Rdb.SetNX(ctx, seenKey, now.Unix(), ViewDedupWindow).Result()
+	if err != nil || !first {
+		return err
+	}
+
+	hourKey := viewsHourKey(tenantID, now)
+
+	pipe := constants.Rdb.TxPipeline()
+	pipe.HIncrBy(ctx, viewsPendingKey, fmt.Sprint(newsID), 1)
+	pipe.ZIncrBy(ctx, hourKey, 1, fmt.Sprint(newsID))
+	pipe.Expire(ctx, hourKey, MaxTrendingWindow+time.Hour)
+
+	_, err = pipe.Exec(ctx)
+
+	return err
+}
+
+// StartViewFlush adds the views counted in Redis to the news in the database
+func StartViewFlush(newsRepository repository.NewsInterface, interval time.Duration) {
+	RunJob("view-flush", interval, func() error {
+		return FlushViews(newsRepository)
+	})
+}
+
+func FlushViews(newsRepository repository.NewsInterface) error {
+	pending, err := constants.Rdb.HGetAll(ctx, viewsPendingKey).Result()
+	if err != nil {
+		return err
+	}
+
+	views := map[uint]int64{}
+	flushed := []interface{}{}
+
+	for id, count := range pending {
+		newsID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		viewCount, err := strconv.ParseInt(count, 10, 64)
+		if err != nil || viewCount <= 0 {
+			continue
+		}
+
+		views[uint(newsID)] = viewCount
+		flushed = append(flushed, id, viewCount)
+	}
+
+	if len(views) == 0 {
+		return nil
+	}
+
+	if err := newsRepository.AddViews(views); err != nil {
+		return err
+	}
+
+	return flushViews.Run(ctx, constants.Rdb, []string{viewsPendingKey}, flushed...).Err()
+}
+
+// ReadTrending returns the most viewed news of a tenant during the last
+// window, rounded up to whole hours, skipping the first offset ones
+func ReadTrending(tenantID uint, window time.Duration, offset, limit int64) ([]NewsViews, error) {
+	hours := int((window + time.Hour - 1) / time.Hour)
+	trendingKey := viewsTrendingPrefix + fmt.Sprint(tenantID) + ":" + fmt.Sprint(hours)
+
+	exists, err := constants.Rdb.Exists(ctx, trendingKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if exists == 0 {
+		now := time.Now()
+		keys := []string{}
+
+		for i := 0; i < hours; i++ {
+			keys = append(keys, viewsHourKey(tenantID, now.Add(-time.Duration(i)*time.Hour)))
+		}
+
+		pipe := constants.Rdb.TxPipeline()
+		pipe.ZUnionStore(ctx, trendingKey, &redis.ZStore{Keys: keys})
+		pipe.Expire(ctx, trendingKey, time.Minute)
+
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	members, err := constants.Rdb.ZRevRangeWithScores(ctx, trendingKey, offset, offset+limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	trending := []NewsViews{}
+
+	for _, member := range members {
+		newsID, err := strconv.ParseUint(fmt.Sprint(member.Member), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		trending = append(trending, NewsViews{NewsID: uint(newsID), Views: int64(member.Score)})
+	}
+
+	return trending, nil
+}
+
+func viewsHourKey(tenantID uint, t time.Time) string {
+	return viewsHourPrefix + fmt.Sprint(tenantID) + ":" + t.UTC().Format("2006010215")
+}
@@ -0,0 +1,171 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	config "github.com/furqonzt99/news-redis/configs"
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/furqonzt99/news-redis/delivery/controllers/apikeys"
+	"github.com/furqonzt99/news-redis/delivery/middlewares"
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/furqonzt99/news-redis/utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type apiKeyResponse struct {
+	Code int                    `json:"code"`
+	Data apikeys.APIKeyResponse `json:"data"`
+}
+
+func TestAPIKey(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	kr := repository.NewAPIKeyRepository(db)
+
+	kc := apikeys.NewAPIKeyController(kr)
+
+	admin := echo.New()
+
+	admin.Validator = &common.Validator{Validator: validator.New()}
+
+	admin.HTTPErrorHandler = common.ErrorHandler
+
+	admin.Use(actAs(entity.RoleAdmin))
+
+	admin.POST("/apikeys", kc.Create)
+	admin.GET("/apikeys", kc.ReadAll)
+	admin.DELETE("/apikeys/:id", kc.Revoke)
+
+	client := echo.New()
+
+	client.HTTPErrorHandler = common.ErrorHandler
+
+	middlewares.TenantMiddleware(client, repository.NewTenantRepository(db))
+
+	auth := middlewares.AuthMiddleware(kr)
+	ok := func(c echo.Context) error {
+		return c.JSON(http.StatusOK, common.SuccessResponseWithData(common.Actor(c), "database"))
+	}
+
+	client.POST("/news", ok, auth, middlewares.PermissionMiddleware(entity.PermNewsWrite))
+	client.POST("/tags", ok, auth, middlewares.PermissionMiddleware(entity.PermTagsManage))
+
+	callWithKey := func(path string, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(echo.POST, path, nil)
+		req.Header.Set(echo.HeaderAuthorization, "ApiKey "+key)
+
+		rec := httptest.NewRecorder()
+
+		client.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	var apiKey apikeys.APIKeyResponse
+
+	t.Run("Create api key success", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+
+		createRequest, _ := json.Marshal(apikeys.CreateAPIKeyRequest{
+			Name:      "partner",
+			Scopes:    []string{"news:read", "news:write"},
+			ExpiresAt: &expiresAt,
+		})
+
+		req := httptest.NewRequest(echo.POST, "/apikeys", bytes.NewBuffer(createRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		admin.ServeHTTP(rec, req)
+
+		var response apiKeyResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.NotEmpty(t, response.Data.Key)
+		assert.Equal(t, []string{"news:read", "news:write"}, response.Data.Scopes)
+
+		apiKey = response.Data
+	})
+
+	t.Run("Create api key unknown scope", func(t *testing.T) {
+		createRequest, _ := json.Marshal(apikeys.CreateAPIKeyRequest{
+			Name:   "partner",
+			Scopes: []string{"news:everything"},
+		})
+
+		req := httptest.NewRequest(echo.POST, "/apikeys", bytes.NewBuffer(createRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		admin.ServeHTTP(rec, req)
+
+		var response common.ResponseError
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "scopes", response.Errors[0].Field)
+	})
+
+	t.Run("Get api keys hides the key", func(t *testing.T) {
+		req := httptest.NewRequest(echo.GET, "/apikeys", nil)
+
+		rec := httptest.NewRecorder()
+
+		admin.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.NotContains(t, rec.Body.String(), apiKey.Key)
+	})
+
+	t.Run("Api key within its scopes", func(t *testing.T) {
+		rec := callWithKey("/news", apiKey.Key)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "apikey:partner", response.Data)
+	})
+
+	t.Run("Api key outside its scopes", func(t *testing.T) {
+		rec := callWithKey("/tags", apiKey.Key)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Unknown api key", func(t *testing.T) {
+		rec := callWithKey("/news", "nr_unknown")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Revoked api key", func(t *testing.T) {
+		req := httptest.NewRequest(echo.DELETE, "/apikeys/"+fmt.Sprint(apiKey.ID), nil)
+
+		rec := httptest.NewRecorder()
+
+		admin.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = callWithKey("/news", apiKey.Key)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
@@ -0,0 +1,404 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	config "github.com/furqonzt99/news-redis/configs"
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/furqonzt99/news-redis/delivery/controllers/auth"
+	"github.com/furqonzt99/news-redis/delivery/controllers/news"
+	"github.com/furqonzt99/news-redis/delivery/middlewares"
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/furqonzt99/news-redis/utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// seededUserIDs are the ids of the users created by seeder.UserSeeder
+var seededUserIDs = map[entity.Role]uint{
+	entity.RoleAdmin:  1,
+	entity.RoleEditor: 2,
+	entity.RoleWriter: 3,
+}
+
+// actAs authenticates every request as the seeded user with the given role
+func actAs(role entity.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			common.SetIdentity(c, common.Identity{UserID: seededUserIDs[role], Username: string(role), Role: role})
+			return next(c)
+		}
+	}
+}
+
+type tokenResponse struct {
+	Code int                `json:"code"`
+	Data auth.TokenResponse `json:"data"`
+}
+
+func TestAuth(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	middlewares.TenantMiddleware(e, repository.NewTenantRepository(db))
+
+	ur := repository.NewUserRepository(db)
+
+	ac := auth.NewAuthController(ur)
+
+	e.POST("/auth/login", ac.Login)
+	e.POST("/auth/refresh", ac.Refresh)
+	e.POST("/auth/logout", ac.Logout, middlewares.JWTMiddleware())
+	e.GET("/me", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, common.SuccessResponseWithData(common.Actor(c), "database"))
+	}, middlewares.JWTMiddleware())
+
+	password, _ := utils.HashPassword("secret")
+	ur.Create(entity.User{Username: "reporter", Password: password})
+
+	post := func(path string, body interface{}, token string) *httptest.ResponseRecorder {
+		requestBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(echo.POST, path, bytes.NewBuffer(requestBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	var tokens auth.TokenResponse
+
+	t.Run("Login success", func(t *testing.T) {
+		rec := post("/auth/login", auth.LoginRequest{Username: "reporter", Password: "secret"}, "")
+
+		var response tokenResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.NotEmpty(t, response.Data.AccessToken)
+		assert.NotEmpty(t, response.Data.RefreshToken)
+
+		tokens = response.Data
+	})
+
+	t.Run("Login wrong password", func(t *testing.T) {
+		rec := post("/auth/login", auth.LoginRequest{Username: "reporter", Password: "wrong"}, "")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Login unknown user", func(t *testing.T) {
+		rec := post("/auth/login", auth.LoginRequest{Username: "nobody", Password: "secret"}, "")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Access with token success", func(t *testing.T) {
+		req := httptest.NewRequest(echo.GET, "/me", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+tokens.AccessToken)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "reporter", response.Data)
+	})
+
+	t.Run("Access without token", func(t *testing.T) {
+		req := httptest.NewRequest(echo.GET, "/me", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Refresh success", func(t *testing.T) {
+		rec := post("/auth/refresh", auth.RefreshRequest{RefreshToken: tokens.RefreshToken}, "")
+
+		var response tokenResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		used := tokens.RefreshToken
+		tokens = response.Data
+
+		rec = post("/auth/refresh", auth.RefreshRequest{RefreshToken: used}, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Refresh with access token", func(t *testing.T) {
+		rec := post("/auth/refresh", auth.RefreshRequest{RefreshToken: tokens.AccessToken}, "")
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Logout revokes tokens", func(t *testing.T) {
+		rec := post("/auth/logout", auth.LogoutRequest{RefreshToken: tokens.RefreshToken}, tokens.AccessToken)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = post("/auth/logout", auth.LogoutRequest{}, tokens.AccessToken)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec = post("/auth/refresh", auth.RefreshRequest{RefreshToken: tokens.RefreshToken}, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestPermission(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleWriter))
+
+	nr := repository.NewNewsRepository(db)
+
+	nc := news.NewNewsController(nr)
+
+	t.Run("Writer cannot publish news", func(t *testing.T) {
+		e.PUT("/news/:id/publish", nc.SetStatusPublish, middlewares.PermissionMiddleware(entity.PermNewsPublish))
+
+		req := httptest.NewRequest(echo.PUT, "/news/2/publish", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Writer cannot publish news through status", func(t *testing.T) {
+		e.PUT("/news/:id/status", nc.SetStatus)
+
+		statusRequest, _ := json.Marshal(news.StatusRequest{Status: "publish"})
+
+		req := httptest.NewRequest(echo.PUT, "/news/2/status", bytes.NewBuffer(statusRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Writer cannot hard delete news", func(t *testing.T) {
+		e.DELETE("/news/:id", nc.Delete)
+
+		req := httptest.NewRequest(echo.DELETE, "/news/2?hard=true", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Writer cannot edit news of other authors", func(t *testing.T) {
+		e.PUT("/news/:id", nc.Edit)
+
+		newsRequest, _ := json.Marshal(news.UpdateNewsRequest{Title: "Not My Title", Tags: []int{1}})
+
+		req := httptest.NewRequest(echo.PUT, "/news/2", bytes.NewBuffer(newsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Writer cannot schedule news", func(t *testing.T) {
+		e.POST("/news", nc.Create)
+
+		publishAt := time.Now().Add(time.Hour)
+
+		newsRequest, _ := json.Marshal(news.CreateNewsRequest{
+			Title:     "Scheduled Title",
+			Body:      "Scheduled Body",
+			Tags:      []int{2},
+			PublishAt: &publishAt,
+		})
+
+		req := httptest.NewRequest(echo.POST, "/news", bytes.NewBuffer(newsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Writer can edit own news", func(t *testing.T) {
+		newsDB, err := nr.Create(entity.News{
+			Title: "Writer Title",
+			Body:  "Writer Body",
+		}, entity.TagSelection{IDs: []int{1}}, []uint{seededUserIDs[entity.RoleWriter]})
+		assert.Nil(t, err)
+
+		e.PUT("/news/:id", nc.Edit)
+
+		newsRequest, _ := json.Marshal(news.UpdateNewsRequest{Title: "Writer Title Edited", Tags: []int{1}})
+
+		req := httptest.NewRequest(echo.PUT, fmt.Sprintf("/news/%d", newsDB.ID), bytes.NewBuffer(newsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Writer cannot change the authors of own news", func(t *testing.T) {
+		newsDB, err := nr.Create(entity.News{
+			Title: "Authored Writer Title",
+			Body:  "Authored Writer Body",
+		}, entity.TagSelection{IDs: []int{1}}, []uint{seededUserIDs[entity.RoleWriter]})
+		assert.Nil(t, err)
+
+		defer nr.Purge(int(newsDB.ID))
+
+		e.PUT("/news/:id", nc.Edit)
+
+		newsRequest, _ := json.Marshal(news.UpdateNewsRequest{
+			Title:   "Authored Writer Title Edited",
+			Tags:    []int{1},
+			Authors: []uint{seededUserIDs[entity.RoleEditor]},
+		})
+
+		req := httptest.NewRequest(echo.PUT, fmt.Sprintf("/news/%d", newsDB.ID), bytes.NewBuffer(newsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Writer cannot create news for other authors", func(t *testing.T) {
+		e.POST("/news", nc.Create)
+
+		newsRequest, _ := json.Marshal(news.CreateNewsRequest{
+			Title:   "Shared Title",
+			Body:    "Shared Body",
+			Tags:    []int{1},
+			Authors: []uint{seededUserIDs[entity.RoleEditor]},
+		})
+
+		req := httptest.NewRequest(echo.POST, "/news", bytes.NewBuffer(newsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+
+	t.Run("Get news by author", func(t *testing.T) {
+		e.GET("/news", nc.ReadAll)
+
+		req := httptest.NewRequest(echo.GET, "/news?author=writer", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data, _ := response.Data.([]interface{})
+		assert.Equal(t, 1, len(data))
+	})
+
+	t.Run("Writer cannot edit own published news", func(t *testing.T) {
+		newsDB, err := nr.Create(entity.News{
+			Title: "Published Writer Title",
+			Body:  "Published Writer Body",
+		}, entity.TagSelection{IDs: []int{1}}, []uint{seededUserIDs[entity.RoleWriter]})
+		assert.Nil(t, err)
+
+		defer nr.Purge(int(newsDB.ID))
+
+		_, err = nr.SetStatusPublish(int(newsDB.ID), "editor")
+		assert.Nil(t, err)
+
+		e.PUT("/news/:id", nc.Edit)
+
+		newsRequest, _ := json.Marshal(news.UpdateNewsRequest{Title: "Published Writer Title Edited", Tags: []int{1}})
+
+		req := httptest.NewRequest(echo.PUT, fmt.Sprintf("/news/%d", newsDB.ID), bytes.NewBuffer(newsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusForbidden, response.Code)
+	})
+}
@@ -3,9 +3,12 @@ package test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	config "github.com/furqonzt99/news-redis/configs"
 	"github.com/furqonzt99/news-redis/constants"
@@ -14,6 +17,7 @@ import (
 	"github.com/furqonzt99/news-redis/domain/entity"
 	"github.com/furqonzt99/news-redis/domain/repository"
 	"github.com/furqonzt99/news-redis/seeder"
+	"github.com/furqonzt99/news-redis/services"
 	"github.com/furqonzt99/news-redis/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
@@ -24,12 +28,17 @@ func TestMain(m *testing.M) {
 	config := config.GetConfig()
 	db := utils.InitDB(config)
 
-	db.Migrator().DropTable(&entity.News{}, &entity.Tag{}, &entity.NewsTags{})
+	db.Migrator().DropTable(&entity.Tenant{}, &entity.User{}, &entity.APIKey{}, &entity.News{}, &entity.NewsSlug{}, &entity.Tag{}, &entity.NewsTags{}, &entity.NewsAuthors{}, &entity.NewsStatusHistory{}, &entity.NewsRevision{}, &entity.NewsTranslation{}, &entity.TagTranslation{})
 
 	utils.InitialMigrate(db)
 
 	constants.Rdb = utils.InitRedis(config)
 
+	services.ConfigureAuth(config.Auth.Secret, config.Auth.AccessTokenTTL, config.Auth.RefreshTokenTTL)
+
+	common.ConfigureLocales(config.Locale.Default, config.Locale.Supported)
+
+	seeder.UserSeeder(db)
 	seeder.TagSeeder(db)
 	seeder.NewsSeeder(db)
 	seeder.NewsTagsSeeder(db)
@@ -45,6 +54,10 @@ func TestCreateNews(t *testing.T) {
 
 	e.Validator = &common.Validator{Validator: validator.New()}
 
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleAdmin))
+
 	nr := repository.NewNewsRepository(db)
 
 	nc := news.NewNewsController(nr)
@@ -86,13 +99,14 @@ func TestCreateNews(t *testing.T) {
 
 		e.ServeHTTP(rec, req)
 
-		var response common.DefaultResponse
+		var response common.ResponseError
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
 		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "tags", response.Errors[0].Field)
 	})
 
-	t.Run("Create news bad request repository", func(t *testing.T) {
+	t.Run("Create news unknown tags", func(t *testing.T) {
 		e.POST("/news", nc.Create)
 
 		createNewsRequest, _ := json.Marshal(news.CreateNewsRequest{
@@ -108,10 +122,57 @@ func TestCreateNews(t *testing.T) {
 
 		e.ServeHTTP(rec, req)
 
+		var response common.ResponseError
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		assert.Equal(t, 2, len(response.Errors))
+	})
+
+	t.Run("Create news with tag names", func(t *testing.T) {
+		e.POST("/news", nc.Create)
+
+		createNewsRequest, _ := json.Marshal(news.CreateNewsRequest{
+			Title:      "Test Title",
+			Body:       "Test Body",
+			TagNames:   []string{"topic1", "Brand New Topic"},
+			CreateTags: true,
+		})
+
+		req := httptest.NewRequest(echo.POST, "/news", bytes.NewBuffer(createNewsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
 		var response common.DefaultResponse
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
-		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Create news unknown tag names", func(t *testing.T) {
+		e.POST("/news", nc.Create)
+
+		createNewsRequest, _ := json.Marshal(news.CreateNewsRequest{
+			Title:    "Test Title",
+			Body:     "Test Body",
+			TagNames: []string{"Missing Topic"},
+		})
+
+		req := httptest.NewRequest(echo.POST, "/news", bytes.NewBuffer(createNewsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseError
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+		assert.Equal(t, "tag_names", response.Errors[0].Field)
 	})
 }
 
@@ -123,6 +184,10 @@ func TestGetNews(t *testing.T) {
 
 	e.Validator = &common.Validator{Validator: validator.New()}
 
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleEditor))
+
 	nr := repository.NewNewsRepository(db)
 
 	nc := news.NewNewsController(nr)
@@ -159,6 +224,100 @@ func TestGetNews(t *testing.T) {
 		assert.Equal(t, "cache", response.Source)
 	})
 
+	t.Run("Get related news success from database", func(t *testing.T) {
+		e.GET("/news/:id/related", nc.ReadRelated)
+
+		req := httptest.NewRequest(echo.GET, "/news/2/related?limit=3", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "database", response.Source)
+	})
+
+	t.Run("Get related news success from cache", func(t *testing.T) {
+		e.GET("/news/:id/related", nc.ReadRelated)
+
+		req := httptest.NewRequest(echo.GET, "/news/2/related?limit=3", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "cache", response.Source)
+	})
+
+	t.Run("Get related news bad request limit", func(t *testing.T) {
+		e.GET("/news/:id/related", nc.ReadRelated)
+
+		req := httptest.NewRequest(echo.GET, "/news/2/related?limit=0", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Get news by slug success from database", func(t *testing.T) {
+		e.GET("/news/slug/:slug", nc.ReadBySlug)
+
+		req := httptest.NewRequest(echo.GET, "/news/slug/title-2", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "database", response.Source)
+	})
+
+	t.Run("Get news by slug success from cache", func(t *testing.T) {
+		e.GET("/news/slug/:slug", nc.ReadBySlug)
+
+		req := httptest.NewRequest(echo.GET, "/news/slug/title-2", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "cache", response.Source)
+	})
+
+	t.Run("Get news by slug not found", func(t *testing.T) {
+		e.GET("/news/slug/:slug", nc.ReadBySlug)
+
+		req := httptest.NewRequest(echo.GET, "/news/slug/no-such-news", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+
 	t.Run("Get one news bad request", func(t *testing.T) {
 		e.GET("/news/:id", nc.ReadOne)
 
@@ -316,9 +475,25 @@ func TestGetNews(t *testing.T) {
 		assert.Equal(t, http.StatusOK, response.Code)
 		assert.Equal(t, "cache", response.Source)
 	})
+
+	t.Run("Get all news success (topic with children) from database", func(t *testing.T) {
+		e.GET("/news", nc.ReadAll)
+
+		req := httptest.NewRequest(echo.GET, "/news?topic=topic1&include_children=true", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "database", response.Source)
+	})
 }
 
-func TestEditNews(t *testing.T) {
+func TestPublicNews(t *testing.T) {
 	config := config.GetConfig()
 	db := utils.InitDB(config)
 
@@ -326,42 +501,41 @@ func TestEditNews(t *testing.T) {
 
 	e.Validator = &common.Validator{Validator: validator.New()}
 
+	e.HTTPErrorHandler = common.ErrorHandler
+
 	nr := repository.NewNewsRepository(db)
 
 	nc := news.NewNewsController(nr)
 
-	t.Run("Update news success", func(t *testing.T) {
-		e.PUT("/news/:id", nc.Edit)
+	draft, _ := nr.Create(entity.News{
+		Title: "Public Draft Title",
+		Body:  "Public Draft Body",
+	}, entity.TagSelection{IDs: []int{1}}, nil)
 
-		updateNewsRequest, _ := json.Marshal(news.UpdateNewsRequest{
-			Title: "Test Title New",
-			Body:  "Test Body New",
-			Tags:  []int{1, 2, 3},
-		})
+	t.Run("Get all news only returns published news", func(t *testing.T) {
+		e.GET("/news", nc.ReadAll)
 
-		req := httptest.NewRequest(echo.PUT, "/news/1", bytes.NewBuffer(updateNewsRequest))
-		req.Header.Set("Content-Type", "application/json")
+		req := httptest.NewRequest(echo.GET, "/news", nil)
 
 		rec := httptest.NewRecorder()
 
 		e.ServeHTTP(rec, req)
 
-		var response common.DefaultResponse
+		var response common.ResponseSuccess
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
 		assert.Equal(t, http.StatusOK, response.Code)
-	})
 
-	t.Run("Update news bad request validator", func(t *testing.T) {
-		e.PUT("/news/:id", nc.Edit)
+		data, _ := response.Data.([]interface{})
+		for _, item := range data {
+			assert.Equal(t, string(entity.StatusPublish), item.(map[string]interface{})["status"])
+		}
+	})
 
-		updateNewsRequest, _ := json.Marshal(news.UpdateNewsRequest{
-			Title: "Test Title",
-			Body:  "Test Body",
-		})
+	t.Run("Get all news by other status unauthorized", func(t *testing.T) {
+		e.GET("/news", nc.ReadAll)
 
-		req := httptest.NewRequest(echo.PUT, "/news/1", bytes.NewBuffer(updateNewsRequest))
-		req.Header.Set("Content-Type", "application/json")
+		req := httptest.NewRequest(echo.GET, "/news?status=draft", nil)
 
 		rec := httptest.NewRecorder()
 
@@ -370,19 +544,13 @@ func TestEditNews(t *testing.T) {
 		var response common.DefaultResponse
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
-		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
 	})
 
-	t.Run("Update news bad request param", func(t *testing.T) {
-		e.PUT("/news/:id", nc.Edit)
-
-		updateNewsRequest, _ := json.Marshal(news.UpdateNewsRequest{
-			Title: "Test Title",
-			Body:  "Test Body",
-		})
+	t.Run("Get one draft news not found", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne)
 
-		req := httptest.NewRequest(echo.PUT, "/news/abc", bytes.NewBuffer(updateNewsRequest))
-		req.Header.Set("Content-Type", "application/json")
+		req := httptest.NewRequest(echo.GET, fmt.Sprintf("/news/%d", draft.ID), nil)
 
 		rec := httptest.NewRecorder()
 
@@ -391,48 +559,28 @@ func TestEditNews(t *testing.T) {
 		var response common.DefaultResponse
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
-		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, http.StatusNotFound, response.Code)
 	})
 
-	t.Run("Update news not found", func(t *testing.T) {
-		e.PUT("/news/:id", nc.Edit)
-
-		updateNewsRequest, _ := json.Marshal(news.UpdateNewsRequest{
-			Title: "Test Title",
-			Body:  "Test Body",
-			Tags:  []int{1, 2},
-		})
+	t.Run("Get one draft news as editor", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne, actAs(entity.RoleEditor))
 
-		req := httptest.NewRequest(echo.PUT, "/news/9999", bytes.NewBuffer(updateNewsRequest))
-		req.Header.Set("Content-Type", "application/json")
+		req := httptest.NewRequest(echo.GET, fmt.Sprintf("/news/%d", draft.ID), nil)
 
 		rec := httptest.NewRecorder()
 
 		e.ServeHTTP(rec, req)
 
-		var response common.DefaultResponse
+		var response common.ResponseSuccess
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
-		assert.Equal(t, http.StatusNotFound, response.Code)
+		assert.Equal(t, http.StatusOK, response.Code)
 	})
-}
-
-func TestSetPublishNews(t *testing.T) {
-	config := config.GetConfig()
-	db := utils.InitDB(config)
-
-	e := echo.New()
-
-	e.Validator = &common.Validator{Validator: validator.New()}
-
-	nr := repository.NewNewsRepository(db)
-
-	nc := news.NewNewsController(nr)
 
-	t.Run("Set Publish news success", func(t *testing.T) {
-		e.PUT("/news/:id/publish", nc.SetStatusPublish)
+	t.Run("Get one draft news cached for editors not found", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne)
 
-		req := httptest.NewRequest(echo.PUT, "/news/1/publish", nil)
+		req := httptest.NewRequest(echo.GET, fmt.Sprintf("/news/%d", draft.ID), nil)
 
 		rec := httptest.NewRecorder()
 
@@ -441,13 +589,13 @@ func TestSetPublishNews(t *testing.T) {
 		var response common.DefaultResponse
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
-		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, http.StatusNotFound, response.Code)
 	})
 
-	t.Run("Set Publish news bad request", func(t *testing.T) {
-		e.PUT("/news/:id/publish", nc.SetStatusPublish)
+	t.Run("Get related news of a draft not found", func(t *testing.T) {
+		e.GET("/news/:id/related", nc.ReadRelated)
 
-		req := httptest.NewRequest(echo.PUT, "/news/qwer/publish", nil)
+		req := httptest.NewRequest(echo.GET, fmt.Sprintf("/news/%d/related", draft.ID), nil)
 
 		rec := httptest.NewRecorder()
 
@@ -456,26 +604,26 @@ func TestSetPublishNews(t *testing.T) {
 		var response common.DefaultResponse
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
-		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, http.StatusNotFound, response.Code)
 	})
 
-	t.Run("Set Publish news not found", func(t *testing.T) {
-		e.PUT("/news/:id/publish", nc.SetStatusPublish)
+	t.Run("Get related news of a draft as editor", func(t *testing.T) {
+		e.GET("/news/:id/related", nc.ReadRelated, actAs(entity.RoleEditor))
 
-		req := httptest.NewRequest(echo.PUT, "/news/9999/publish", nil)
+		req := httptest.NewRequest(echo.GET, fmt.Sprintf("/news/%d/related", draft.ID), nil)
 
 		rec := httptest.NewRecorder()
 
 		e.ServeHTTP(rec, req)
 
-		var response common.DefaultResponse
+		var response common.ResponseSuccess
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
-		assert.Equal(t, http.StatusNotFound, response.Code)
+		assert.Equal(t, http.StatusOK, response.Code)
 	})
 }
 
-func TestSetDraftNews(t *testing.T) {
+func TestBodyFormatNews(t *testing.T) {
 	config := config.GetConfig()
 	db := utils.InitDB(config)
 
@@ -483,29 +631,32 @@ func TestSetDraftNews(t *testing.T) {
 
 	e.Validator = &common.Validator{Validator: validator.New()}
 
-	nr := repository.NewNewsRepository(db)
-
-	nc := news.NewNewsController(nr)
-
-	t.Run("Set Draft news success", func(t *testing.T) {
-		e.PUT("/news/:id/draft", nc.SetStatusDraft)
+	e.HTTPErrorHandler = common.ErrorHandler
 
-		req := httptest.NewRequest(echo.PUT, "/news/1/draft", nil)
+	e.Use(actAs(entity.RoleAdmin))
 
-		rec := httptest.NewRecorder()
+	nr := repository.NewNewsRepository(db)
 
-		e.ServeHTTP(rec, req)
+	nc := news.NewNewsController(nr)
 
-		var response common.DefaultResponse
-		json.Unmarshal(rec.Body.Bytes(), &response)
+	markdown, _ := nr.Create(entity.News{
+		Title:      "Markdown Title",
+		Body:       "Some **bold** text <script>alert(1)</script>",
+		BodyFormat: entity.FormatMarkdown,
+	}, entity.TagSelection{IDs: []int{1}}, nil)
 
-		assert.Equal(t, http.StatusOK, response.Code)
-	})
+	t.Run("Create news with unknown body format", func(t *testing.T) {
+		e.POST("/news", nc.Create)
 
-	t.Run("Set Draft news bad request", func(t *testing.T) {
-		e.PUT("/news/:id/draft", nc.SetStatusDraft)
+		reqBody, _ := json.Marshal(news.CreateNewsRequest{
+			Title:      "Title",
+			Body:       "Body",
+			BodyFormat: "rtf",
+			Tags:       []int{1},
+		})
 
-		req := httptest.NewRequest(echo.PUT, "/news/qwer/draft", nil)
+		req := httptest.NewRequest(echo.POST, "/news", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
 
 		rec := httptest.NewRecorder()
 
@@ -517,22 +668,41 @@ func TestSetDraftNews(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, response.Code)
 	})
 
-	t.Run("Set Draft news not found", func(t *testing.T) {
-		e.PUT("/news/:id/draft", nc.SetStatusDraft)
+	t.Run("Create news with html body is sanitized", func(t *testing.T) {
+		created, err := nr.Create(entity.News{
+			Title:      "HTML Title",
+			Body:       `<p onclick="steal()">Hello</p><script>alert(1)</script>`,
+			BodyFormat: entity.FormatHTML,
+		}, entity.TagSelection{IDs: []int{1}}, nil)
 
-		req := httptest.NewRequest(echo.PUT, "/news/9999/draft", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "<p>Hello</p>", created.Body)
+		assert.Equal(t, "<p>Hello</p>", created.BodyHTML)
+	})
+
+	t.Run("Get news with rendered markdown", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne)
+
+		req := httptest.NewRequest(echo.GET, fmt.Sprintf("/news/%d", markdown.ID), nil)
 
 		rec := httptest.NewRecorder()
 
 		e.ServeHTTP(rec, req)
 
-		var response common.DefaultResponse
+		var response common.ResponseSuccess
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
-		assert.Equal(t, http.StatusNotFound, response.Code)
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data, _ := response.Data.(map[string]interface{})
+		assert.Equal(t, "markdown", data["body_format"])
+		assert.Equal(t, "Some **bold** text <script>alert(1)</script>", data["body"])
+		assert.Contains(t, data["body_html"], "<strong>bold</strong>")
+		assert.NotContains(t, data["body_html"], "<script>")
 	})
 }
-func TestSetDeletedNews(t *testing.T) {
+
+func TestSummaryNews(t *testing.T) {
 	config := config.GetConfig()
 	db := utils.InitDB(config)
 
@@ -540,29 +710,740 @@ func TestSetDeletedNews(t *testing.T) {
 
 	e.Validator = &common.Validator{Validator: validator.New()}
 
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleAdmin))
+
 	nr := repository.NewNewsRepository(db)
 
 	nc := news.NewNewsController(nr)
 
-	t.Run("Set Deleted news success", func(t *testing.T) {
-		e.PUT("/news/:id/deleted", nc.SetStatusDeleted)
+	summary := "A short summary"
 
-		req := httptest.NewRequest(echo.PUT, "/news/1/deleted", nil)
+	t.Run("Create news with a long body gets an excerpt", func(t *testing.T) {
+		created, err := nr.Create(entity.News{
+			Title: "Long Title",
+			Body:  strings.Repeat("word ", 450),
+		}, entity.TagSelection{IDs: []int{1}}, nil)
 
-		rec := httptest.NewRecorder()
+		assert.Nil(t, err)
+		assert.Equal(t, 450, created.WordCount)
+		assert.Equal(t, 3, created.ReadingTime)
+		assert.Equal(t, strings.TrimSpace(strings.Repeat("word ", 50))+"…", created.Excerpt)
+	})
+
+	t.Run("Create news with a summary", func(t *testing.T) {
+		created, err := nr.Create(entity.News{
+			Title:   "Summary Title",
+			Body:    "Summary Body",
+			Summary: &summary,
+		}, entity.TagSelection{IDs: []int{1}}, nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, summary, created.Excerpt)
+	})
+
+	t.Run("Edit news to a body without words", func(t *testing.T) {
+		created, _ := nr.Create(entity.News{
+			Title: "Wordy Title",
+			Body:  "Some words to count",
+		}, entity.TagSelection{IDs: []int{1}}, nil)
+
+		edited, err := nr.Edit(int(created.ID), entity.News{
+			Body:       "![](https://example.com/image.png)",
+			BodyFormat: entity.FormatMarkdown,
+		}, entity.TagSelection{IDs: []int{1}}, nil, entity.ScheduleChange{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, edited.WordCount)
+		assert.Equal(t, 0, edited.ReadingTime)
+		assert.Equal(t, "", edited.Excerpt)
+	})
+
+	t.Run("Get all news without body", func(t *testing.T) {
+		e.GET("/news", nc.ReadAll)
+
+		req := httptest.NewRequest(echo.GET, "/news?fields=id,title,summary,reading_time", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data, _ := response.Data.([]interface{})
+		assert.NotEmpty(t, data)
+		for _, item := range data {
+			assert.NotContains(t, item, "body")
+			assert.Contains(t, item, "summary")
+		}
+	})
+
+	t.Run("Get all news with unknown fields", func(t *testing.T) {
+		e.GET("/news", nc.ReadAll)
+
+		req := httptest.NewRequest(echo.GET, "/news?fields=id,secret", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestProjectionNews(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleAdmin))
+
+	nr := repository.NewNewsRepository(db)
+
+	nc := news.NewNewsController(nr)
+
+	created, _ := nr.Create(entity.News{
+		Title: "Projection Title",
+		Body:  "Projection Body",
+	}, entity.TagSelection{IDs: []int{1}}, []uint{seededUserIDs[entity.RoleWriter]})
+
+	path := fmt.Sprintf("/news/%d", created.ID)
+
+	get := func(query string) (common.ResponseSuccess, map[string]interface{}) {
+		req := httptest.NewRequest(echo.GET, path+query, nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		data, _ := response.Data.(map[string]interface{})
+
+		return response, data
+	}
+
+	t.Run("Get one news with tags as objects and timestamps", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne)
+
+		response, data := get("")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, data, "created_at")
+		assert.Contains(t, data, "updated_at")
+		assert.Contains(t, data, "authors")
+
+		tags, _ := data["tags"].([]interface{})
+		if assert.Len(t, tags, 1) {
+			tag := tags[0].(map[string]interface{})
+			assert.Contains(t, tag, "id")
+			assert.Contains(t, tag, "name")
+			assert.Contains(t, tag, "slug")
+		}
+	})
+
+	t.Run("Get one news with chosen fields and tags", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne)
+
+		for i := 0; i < 2; i++ {
+			response, data := get("?fields=id,title&include=tags")
+
+			assert.Equal(t, http.StatusOK, response.Code)
+			assert.Equal(t, 3, len(data))
+			assert.Contains(t, data, "id")
+			assert.Contains(t, data, "title")
+			assert.Contains(t, data, "tags")
+
+			// the second request reads the cache, which must have the same projection
+			time.Sleep(100 * time.Millisecond)
+		}
+	})
+
+	t.Run("Get one news without relations", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne)
+
+		response, data := get("?include=")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.NotContains(t, data, "tags")
+		assert.NotContains(t, data, "authors")
+		assert.Contains(t, data, "body")
+	})
+
+	t.Run("Get one news with unknown include", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne)
+
+		response, _ := get("?include=comments")
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Get published news has published_at", func(t *testing.T) {
+		_, err := nr.SetStatusPublish(int(created.ID), "admin")
+		assert.Nil(t, err)
+
+		e.GET("/news/:id", nc.ReadOne)
+
+		response, data := get("?fields=published_at&include=")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, data, "published_at")
+	})
+}
+
+func TestEditNews(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleAdmin))
+
+	nr := repository.NewNewsRepository(db)
+
+	nc := news.NewNewsController(nr)
+
+	t.Run("Update news success", func(t *testing.T) {
+		e.PUT("/news/:id", nc.Edit)
+
+		updateNewsRequest, _ := json.Marshal(news.UpdateNewsRequest{
+			Title:   "Test Title New",
+			Body:    "Test Body New",
+			Tags:    []int{1, 2, 3},
+			Version: 1,
+		})
+
+		req := httptest.NewRequest(echo.PUT, "/news/1", bytes.NewBuffer(updateNewsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Get news by former slug redirects", func(t *testing.T) {
+		e.GET("/news/slug/:slug", nc.ReadBySlug)
+
+		req := httptest.NewRequest(echo.GET, "/news/slug/title-1", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+		assert.Equal(t, "/news/slug/test-title-new", rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("Get news by former slug redirects with the query", func(t *testing.T) {
+		e.GET("/news/slug/:slug", nc.ReadBySlug)
+
+		req := httptest.NewRequest(echo.GET, "/news/slug/title-1?lang=en&fields=id,title", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+		assert.Equal(t, "/news/slug/test-title-new?lang=en&fields=id,title", rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("Update news stale version", func(t *testing.T) {
+		e.PUT("/news/:id", nc.Edit)
+
+		updateNewsRequest, _ := json.Marshal(news.UpdateNewsRequest{
+			Title: "Test Title Stale",
+			Body:  "Test Body Stale",
+			Tags:  []int{1},
+		})
+
+		req := httptest.NewRequest(echo.PUT, "/news/1", bytes.NewBuffer(updateNewsRequest))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"1"`)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseError
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusConflict, response.Code)
+		assert.Equal(t, float64(2), response.Data.(map[string]interface{})["current_version"])
+	})
+
+	t.Run("Update news missing version", func(t *testing.T) {
+		e.PUT("/news/:id", nc.Edit)
+
+		updateNewsRequest, _ := json.Marshal(news.UpdateNewsRequest{
+			Title: "Test Title",
+			Body:  "Test Body",
+			Tags:  []int{1},
+		})
+
+		req := httptest.NewRequest(echo.PUT, "/news/1", bytes.NewBuffer(updateNewsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Update news bad request validator", func(t *testing.T) {
+		e.PUT("/news/:id", nc.Edit)
+
+		updateNewsRequest, _ := json.Marshal(news.UpdateNewsRequest{
+			Title: "Test Title",
+			Body:  "Test Body",
+		})
+
+		req := httptest.NewRequest(echo.PUT, "/news/1", bytes.NewBuffer(updateNewsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Update news bad request param", func(t *testing.T) {
+		e.PUT("/news/:id", nc.Edit)
+
+		updateNewsRequest, _ := json.Marshal(news.UpdateNewsRequest{
+			Title: "Test Title",
+			Body:  "Test Body",
+		})
+
+		req := httptest.NewRequest(echo.PUT, "/news/abc", bytes.NewBuffer(updateNewsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Update news not found", func(t *testing.T) {
+		e.PUT("/news/:id", nc.Edit)
+
+		updateNewsRequest, _ := json.Marshal(news.UpdateNewsRequest{
+			Title:   "Test Title",
+			Body:    "Test Body",
+			Tags:    []int{1, 2},
+			Version: 1,
+		})
+
+		req := httptest.NewRequest(echo.PUT, "/news/9999", bytes.NewBuffer(updateNewsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("Update news without title keeps slug", func(t *testing.T) {
+		newsDB, err := nr.Create(entity.News{
+			Title: "Kept Slug Title",
+			Body:  "Kept Slug Body",
+		}, entity.TagSelection{IDs: []int{1}}, nil)
+		assert.Nil(t, err)
+
+		defer nr.Purge(int(newsDB.ID))
+
+		e.PUT("/news/:id", nc.Edit)
+
+		updateNewsRequest, _ := json.Marshal(news.UpdateNewsRequest{
+			Body:    "Kept Slug Body New",
+			Tags:    []int{1},
+			Version: 1,
+		})
+
+		req := httptest.NewRequest(echo.PUT, fmt.Sprintf("/news/%d", newsDB.ID), bytes.NewBuffer(updateNewsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		edited, err := nr.ReadOne(int(newsDB.ID))
+		assert.Nil(t, err)
+		assert.Equal(t, "kept-slug-title", edited.Slug)
+	})
+}
+
+func TestSetPublishNews(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleAdmin))
+
+	nr := repository.NewNewsRepository(db)
+
+	nc := news.NewNewsController(nr)
+
+	db.Model(&entity.News{}).Where("id = ?", 1).Update("status", entity.StatusDraft)
+
+	t.Run("Set Publish news success", func(t *testing.T) {
+		e.PUT("/news/:id/publish", nc.SetStatusPublish)
+
+		req := httptest.NewRequest(echo.PUT, "/news/1/publish", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Set Publish news bad request", func(t *testing.T) {
+		e.PUT("/news/:id/publish", nc.SetStatusPublish)
+
+		req := httptest.NewRequest(echo.PUT, "/news/qwer/publish", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Set Publish news not found", func(t *testing.T) {
+		e.PUT("/news/:id/publish", nc.SetStatusPublish)
+
+		req := httptest.NewRequest(echo.PUT, "/news/9999/publish", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+}
+
+func TestSetDraftNews(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleAdmin))
+
+	nr := repository.NewNewsRepository(db)
+
+	nc := news.NewNewsController(nr)
+
+	t.Run("Set Draft news success", func(t *testing.T) {
+		e.PUT("/news/:id/draft", nc.SetStatusDraft)
+
+		req := httptest.NewRequest(echo.PUT, "/news/1/draft", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Set Draft news bad request", func(t *testing.T) {
+		e.PUT("/news/:id/draft", nc.SetStatusDraft)
+
+		req := httptest.NewRequest(echo.PUT, "/news/qwer/draft", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Set Draft news not found", func(t *testing.T) {
+		e.PUT("/news/:id/draft", nc.SetStatusDraft)
+
+		req := httptest.NewRequest(echo.PUT, "/news/9999/draft", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+}
+func TestSetDeletedNews(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleAdmin))
+
+	nr := repository.NewNewsRepository(db)
+
+	nc := news.NewNewsController(nr)
+
+	t.Run("Set Deleted news success", func(t *testing.T) {
+		e.PUT("/news/:id/deleted", nc.SetStatusDeleted)
+
+		req := httptest.NewRequest(echo.PUT, "/news/1/deleted", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Set Deleted news bad request", func(t *testing.T) {
+		e.PUT("/news/:id/deleted", nc.SetStatusDeleted)
+
+		req := httptest.NewRequest(echo.PUT, "/news/qwer/deleted", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Set Deleted news not found", func(t *testing.T) {
+		e.PUT("/news/:id/deleted", nc.SetStatusDeleted)
+
+		req := httptest.NewRequest(echo.PUT, "/news/9999/deleted", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+}
+
+func TestSetStatusNews(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleAdmin))
+
+	nr := repository.NewNewsRepository(db)
+
+	nc := news.NewNewsController(nr)
+
+	t.Run("Set status news invalid transition", func(t *testing.T) {
+		e.PUT("/news/:id/status", nc.SetStatus)
+
+		statusRequest, _ := json.Marshal(news.StatusRequest{
+			Status: "publish",
+		})
+
+		req := httptest.NewRequest(echo.PUT, "/news/1/status", bytes.NewBuffer(statusRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusConflict, response.Code)
+	})
+
+	t.Run("Set status news restore to draft", func(t *testing.T) {
+		e.PUT("/news/:id/status", nc.SetStatus)
+
+		statusRequest, _ := json.Marshal(news.StatusRequest{
+			Status: "draft",
+		})
+
+		req := httptest.NewRequest(echo.PUT, "/news/1/status", bytes.NewBuffer(statusRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Set status news bad request validator", func(t *testing.T) {
+		e.PUT("/news/:id/status", nc.SetStatus)
+
+		statusRequest, _ := json.Marshal(news.StatusRequest{
+			Status: "unknown",
+		})
+
+		req := httptest.NewRequest(echo.PUT, "/news/1/status", bytes.NewBuffer(statusRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Get status history success", func(t *testing.T) {
+		e.GET("/news/:id/status/history", nc.ReadStatusHistory)
+
+		req := httptest.NewRequest(echo.GET, "/news/1/status/history", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+}
+
+func TestRevisionNews(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleAdmin))
+
+	nr := repository.NewNewsRepository(db)
+
+	nc := news.NewNewsController(nr)
+
+	t.Run("Get revisions success", func(t *testing.T) {
+		e.GET("/news/:id/revisions", nc.ReadRevisions)
+
+		req := httptest.NewRequest(echo.GET, "/news/1/revisions", nil)
+
+		rec := httptest.NewRecorder()
 
 		e.ServeHTTP(rec, req)
 
-		var response common.DefaultResponse
+		var response common.ResponseSuccess
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
 		assert.Equal(t, http.StatusOK, response.Code)
 	})
 
-	t.Run("Set Deleted news bad request", func(t *testing.T) {
-		e.PUT("/news/:id/deleted", nc.SetStatusDeleted)
+	t.Run("Get revision success", func(t *testing.T) {
+		e.GET("/news/:id/revisions/:revision", nc.ReadRevision)
 
-		req := httptest.NewRequest(echo.PUT, "/news/qwer/deleted", nil)
+		req := httptest.NewRequest(echo.GET, "/news/1/revisions/1", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Get revision not found", func(t *testing.T) {
+		e.GET("/news/:id/revisions/:revision", nc.ReadRevision)
+
+		req := httptest.NewRequest(echo.GET, "/news/1/revisions/9999", nil)
 
 		rec := httptest.NewRecorder()
 
@@ -571,13 +1452,58 @@ func TestSetDeletedNews(t *testing.T) {
 		var response common.DefaultResponse
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("Diff revisions success", func(t *testing.T) {
+		e.GET("/news/:id/revisions/diff", nc.DiffRevisions)
+
+		req := httptest.NewRequest(echo.GET, "/news/1/revisions/diff?from=1&to=2", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Diff revisions bad request to", func(t *testing.T) {
+		e.GET("/news/:id/revisions/diff", nc.DiffRevisions)
+
+		req := httptest.NewRequest(echo.GET, "/news/1/revisions/diff?from=1&to=last", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseError
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
 		assert.Equal(t, http.StatusBadRequest, response.Code)
+		if assert.Len(t, response.Errors, 1) {
+			assert.Equal(t, "to", response.Errors[0].Field)
+		}
 	})
 
-	t.Run("Set Deleted news not found", func(t *testing.T) {
-		e.PUT("/news/:id/deleted", nc.SetStatusDeleted)
+	t.Run("Diff long bodies is replaced", func(t *testing.T) {
+		from := strings.Repeat("old line\n", 2000) + "same"
+		to := strings.Repeat("new line\n", 2000) + "same"
 
-		req := httptest.NewRequest(echo.PUT, "/news/9999/deleted", nil)
+		lines := utils.DiffLines(from, to)
+
+		assert.Equal(t, 4001, len(lines))
+		assert.Equal(t, "- old line", lines[0])
+		assert.Equal(t, "+ new line", lines[2000])
+		assert.Equal(t, "  same", lines[4000])
+	})
+
+	t.Run("Restore revision success", func(t *testing.T) {
+		e.POST("/news/:id/revisions/:revision/restore", nc.RestoreRevision)
+
+		req := httptest.NewRequest(echo.POST, "/news/1/revisions/1/restore", nil)
 
 		rec := httptest.NewRecorder()
 
@@ -586,7 +1512,269 @@ func TestSetDeletedNews(t *testing.T) {
 		var response common.DefaultResponse
 		json.Unmarshal(rec.Body.Bytes(), &response)
 
-		assert.Equal(t, http.StatusNotFound, response.Code)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+}
+
+func TestScheduleNews(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	nr := repository.NewNewsRepository(db)
+
+	t.Run("Scheduled news is published and unpublished", func(t *testing.T) {
+		publishAt := time.Now().Add(-time.Minute)
+		unpublishAt := time.Now().Add(time.Hour)
+
+		newsDB, err := nr.Create(entity.News{
+			Title:       "Scheduled Title",
+			Body:        "Scheduled Body",
+			PublishAt:   &publishAt,
+			UnpublishAt: &unpublishAt,
+		}, entity.TagSelection{IDs: []int{2}}, nil)
+		assert.Nil(t, err)
+
+		assert.Nil(t, services.RunSchedule(nr, time.Now()))
+
+		newsDB, _ = nr.ReadOne(int(newsDB.ID))
+		assert.Equal(t, entity.StatusPublish, newsDB.Status)
+		assert.Nil(t, newsDB.PublishAt)
+
+		assert.Nil(t, services.RunSchedule(nr, unpublishAt.Add(time.Second)))
+
+		newsDB, _ = nr.ReadOne(int(newsDB.ID))
+		assert.Equal(t, entity.StatusDraft, newsDB.Status)
+		assert.Nil(t, newsDB.UnpublishAt)
+	})
+
+	t.Run("Scheduled news keeps deleted and archived status", func(t *testing.T) {
+		due := time.Now().Add(-time.Minute)
+
+		for _, test := range []struct {
+			status entity.NewsStatus
+			column string
+		}{
+			{entity.StatusDeleted, "publish_at"},
+			{entity.StatusDeleted, "unpublish_at"},
+			{entity.StatusArchived, "publish_at"},
+			{entity.StatusArchived, "unpublish_at"},
+		} {
+			newsDB, err := nr.Create(entity.News{
+				Title: "Pending Title",
+				Body:  "Pending Body",
+			}, entity.TagSelection{IDs: []int{2}}, nil)
+			assert.Nil(t, err)
+
+			if test.status == entity.StatusArchived {
+				nr.SetStatusPublish(int(newsDB.ID), "")
+			}
+
+			nr.SetStatus(int(newsDB.ID), test.status, "")
+
+			db.Model(&entity.News{}).Where("id = ?", newsDB.ID).Update(test.column, due)
+
+			assert.Nil(t, services.RunSchedule(nr, time.Now()))
+
+			newsDB, _ = nr.ReadOne(int(newsDB.ID))
+			assert.Equal(t, test.status, newsDB.Status, test.column)
+			assert.Nil(t, newsDB.PublishAt, test.column)
+			assert.Nil(t, newsDB.UnpublishAt, test.column)
+
+			nr.Purge(int(newsDB.ID))
+		}
+	})
+
+	t.Run("Set status clears schedule", func(t *testing.T) {
+		publishAt := time.Now().Add(time.Hour)
+		unpublishAt := time.Now().Add(2 * time.Hour)
+
+		newsDB, err := nr.Create(entity.News{
+			Title:       "Manual Title",
+			Body:        "Manual Body",
+			PublishAt:   &publishAt,
+			UnpublishAt: &unpublishAt,
+		}, entity.TagSelection{IDs: []int{2}}, nil)
+		assert.Nil(t, err)
+
+		newsDB, err = nr.SetStatusPublish(int(newsDB.ID), "")
+		assert.Nil(t, err)
+		assert.Nil(t, newsDB.PublishAt)
+		assert.Nil(t, newsDB.UnpublishAt)
+	})
+
+	t.Run("Edit news clears schedule", func(t *testing.T) {
+		publishAt := time.Now().Add(time.Hour)
+
+		newsDB, err := nr.Create(entity.News{
+			Title:     "Unscheduled Title",
+			Body:      "Unscheduled Body",
+			PublishAt: &publishAt,
+		}, entity.TagSelection{IDs: []int{2}}, nil)
+		assert.Nil(t, err)
+
+		e := echo.New()
+
+		e.Validator = &common.Validator{Validator: validator.New()}
+
+		e.HTTPErrorHandler = common.ErrorHandler
+
+		e.Use(actAs(entity.RoleAdmin))
+
+		nc := news.NewNewsController(nr)
+
+		e.PUT("/news/:id", nc.Edit)
+
+		updateNewsRequest := `{"tags": [2], "version": 1, "publish_at": null}`
+
+		req := httptest.NewRequest(echo.PUT, fmt.Sprintf("/news/%d", newsDB.ID), strings.NewReader(updateNewsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		newsDB, _ = nr.ReadOne(int(newsDB.ID))
+		assert.Nil(t, newsDB.PublishAt)
+	})
+
+	t.Run("Create news unpublish before publish", func(t *testing.T) {
+		e := echo.New()
+
+		e.Validator = &common.Validator{Validator: validator.New()}
+
+		e.HTTPErrorHandler = common.ErrorHandler
+
+		nc := news.NewNewsController(nr)
+
+		e.POST("/news", nc.Create)
+
+		publishAt := time.Now().Add(time.Hour)
+		unpublishAt := time.Now()
+
+		createNewsRequest, _ := json.Marshal(news.CreateNewsRequest{
+			Title:       "Test Title",
+			Body:        "Test Body",
+			Tags:        []int{1},
+			PublishAt:   &publishAt,
+			UnpublishAt: &unpublishAt,
+		})
+
+		req := httptest.NewRequest(echo.POST, "/news", bytes.NewBuffer(createNewsRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestViewNews(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	nr := repository.NewNewsRepository(db)
+
+	nc := news.NewNewsController(nr)
+
+	t.Run("Views are deduplicated and flushed", func(t *testing.T) {
+		newsDB, err := nr.Create(entity.News{
+			Title: "Viewed Title",
+			Body:  "Viewed Body",
+		}, entity.TagSelection{IDs: []int{2}}, nil)
+		assert.Nil(t, err)
+
+		assert.Nil(t, services.CountView(0, int(newsDB.ID), "test client"))
+		assert.Nil(t, services.CountView(0, int(newsDB.ID), "test client"))
+		assert.Nil(t, services.CountView(0, int(newsDB.ID), "other client"))
+
+		assert.Nil(t, services.FlushViews(nr))
+
+		newsDB, _ = nr.ReadOne(int(newsDB.ID))
+		assert.Equal(t, int64(2), newsDB.ViewCount)
+	})
+
+	t.Run("Get trending news success", func(t *testing.T) {
+		e.GET("/news/trending", nc.ReadTrending)
+
+		req := httptest.NewRequest(echo.GET, "/news/trending?window=24h", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Get trending news skips unpublished news", func(t *testing.T) {
+		draft, err := nr.Create(entity.News{
+			Title: "Trending Draft Title",
+			Body:  "Trending Draft Body",
+		}, entity.TagSelection{IDs: []int{2}}, nil)
+		assert.Nil(t, err)
+
+		published, err := nr.Create(entity.News{
+			Title: "Trending Published Title",
+			Body:  "Trending Published Body",
+		}, entity.TagSelection{IDs: []int{2}}, nil)
+		assert.Nil(t, err)
+
+		_, err = nr.SetStatusPublish(int(published.ID), "editor")
+		assert.Nil(t, err)
+
+		for i := 0; i < 100; i++ {
+			assert.Nil(t, services.CountView(0, int(draft.ID), fmt.Sprint("draft client ", i)))
+		}
+		assert.Nil(t, services.CountView(0, int(published.ID), "published client"))
+
+		e.GET("/news/trending", nc.ReadTrending)
+
+		req := httptest.NewRequest(echo.GET, "/news/trending?window=2h&limit=1", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data, _ := response.Data.([]interface{})
+		assert.Equal(t, 1, len(data))
+	})
+
+	t.Run("Get trending news bad request window", func(t *testing.T) {
+		e.GET("/news/trending", nc.ReadTrending)
+
+		req := httptest.NewRequest(echo.GET, "/news/trending?window=yesterday", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
 	})
 }
 
@@ -598,6 +1786,10 @@ func TestDeleteNews(t *testing.T) {
 
 	e.Validator = &common.Validator{Validator: validator.New()}
 
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleAdmin))
+
 	nr := repository.NewNewsRepository(db)
 
 	nc := news.NewNewsController(nr)
@@ -646,4 +1838,88 @@ func TestDeleteNews(t *testing.T) {
 
 		assert.Equal(t, http.StatusNotFound, response.Code)
 	})
+
+	t.Run("Get trash news success", func(t *testing.T) {
+		e.GET("/news/trash", nc.ReadTrash)
+
+		req := httptest.NewRequest(echo.GET, "/news/trash", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Restore news success", func(t *testing.T) {
+		e.POST("/news/:id/restore", nc.Restore)
+
+		req := httptest.NewRequest(echo.POST, "/news/1/restore", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Restore news records a revision", func(t *testing.T) {
+		revisions, err := nr.ReadRevisions(1)
+
+		assert.Nil(t, err)
+		if assert.NotEmpty(t, revisions) {
+			assert.Equal(t, entity.RevisionUndelete, revisions[len(revisions)-1].Action)
+		}
+	})
+
+	t.Run("Restore news not in trash", func(t *testing.T) {
+		e.POST("/news/:id/restore", nc.Restore)
+
+		req := httptest.NewRequest(echo.POST, "/news/1/restore", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("Hard delete news success", func(t *testing.T) {
+		e.DELETE("/news/:id", nc.Delete)
+
+		req := httptest.NewRequest(echo.DELETE, "/news/1?hard=true", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Restore purged news not found", func(t *testing.T) {
+		e.POST("/news/:id/restore", nc.Restore)
+
+		req := httptest.NewRequest(echo.POST, "/news/1/restore", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
 }
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	config "github.com/furqonzt99/news-redis/configs"
+	"github.com/furqonzt99/news-redis/constants"
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/furqonzt99/news-redis/delivery/middlewares"
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/furqonzt99/news-redis/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	newServer := func() *echo.Echo {
+		e := echo.New()
+
+		e.HTTPErrorHandler = common.ErrorHandler
+
+		e.Use(middlewares.RateLimitMiddleware(middlewares.KeyByAPIKey, middlewares.RateLimitRule{
+			Group:  fmt.Sprint("test-", time.Now().UnixNano()),
+			Limit:  2,
+			Window: time.Minute,
+			Match:  middlewares.WriteRequest,
+		}))
+
+		e.GET("/ping", func(c echo.Context) error {
+			return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+		})
+		e.POST("/ping", func(c echo.Context) error {
+			return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+		})
+
+		return e
+	}
+
+	request := func(e *echo.Echo, method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/ping", nil)
+		req.Header.Set(echo.HeaderAuthorization, fmt.Sprint("ApiKey unknown-", time.Now().UnixNano()))
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	t.Run("Rate limit write requests", func(t *testing.T) {
+		e := newServer()
+
+		rec := request(e, echo.POST)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+
+		rec = request(e, echo.POST)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+		rec = request(e, echo.POST)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get(echo.HeaderRetryAfter))
+	})
+
+	t.Run("Rate limit unknown api keys by ip", func(t *testing.T) {
+		e := newServer()
+
+		// every request sends another key, none of them authenticated
+		assert.Equal(t, http.StatusOK, request(e, echo.POST).Code)
+		assert.Equal(t, http.StatusOK, request(e, echo.POST).Code)
+		assert.Equal(t, http.StatusTooManyRequests, request(e, echo.POST).Code)
+	})
+
+	t.Run("Rate limit failed authentication by ip", func(t *testing.T) {
+		db := utils.InitDB(config.GetConfig())
+
+		e := echo.New()
+
+		e.HTTPErrorHandler = common.ErrorHandler
+
+		e.POST("/ping", func(c echo.Context) error {
+			return c.JSON(http.StatusOK, common.NewSuccessOperationResponse())
+		}, middlewares.RateLimitMiddleware(middlewares.KeyByIP, middlewares.RateLimitRule{
+			Group:  fmt.Sprint("test-auth-", time.Now().UnixNano()),
+			Limit:  2,
+			Window: time.Minute,
+		}), middlewares.AuthMiddleware(repository.NewAPIKeyRepository(db)))
+
+		assert.Equal(t, http.StatusUnauthorized, request(e, echo.POST).Code)
+		assert.Equal(t, http.StatusUnauthorized, request(e, echo.POST).Code)
+		assert.Equal(t, http.StatusTooManyRequests, request(e, echo.POST).Code)
+	})
+
+	t.Run("Rate limit skips unmatched requests", func(t *testing.T) {
+		e := newServer()
+
+		for i := 0; i < 3; i++ {
+			rec := request(e, echo.GET)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+		}
+	})
+
+	t.Run("Rate limit in memory without redis", func(t *testing.T) {
+		rdb := constants.Rdb
+		constants.Rdb = nil
+		defer func() { constants.Rdb = rdb }()
+
+		e := newServer()
+
+		assert.Equal(t, http.StatusOK, request(e, echo.POST).Code)
+		assert.Equal(t, http.StatusOK, request(e, echo.POST).Code)
+		assert.Equal(t, http.StatusTooManyRequests, request(e, echo.POST).Code)
+	})
+}
@@ -3,6 +3,7 @@ package test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -10,7 +11,9 @@ import (
 	config "github.com/furqonzt99/news-redis/configs"
 	"github.com/furqonzt99/news-redis/delivery/common"
 	"github.com/furqonzt99/news-redis/delivery/controllers/tags"
+	"github.com/furqonzt99/news-redis/domain/entity"
 	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/furqonzt99/news-redis/services"
 	"github.com/furqonzt99/news-redis/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
@@ -25,6 +28,8 @@ func TestCreateTag(t *testing.T) {
 
 	e.Validator = &common.Validator{Validator: validator.New()}
 
+	e.HTTPErrorHandler = common.ErrorHandler
+
 	tr := repository.NewTagRepository(db)
 
 	tc := tags.NewTagController(tr)
@@ -49,6 +54,52 @@ func TestCreateTag(t *testing.T) {
 		assert.Equal(t, http.StatusOK, response.Code)
 	})
 
+	t.Run("Create tag duplicate name", func(t *testing.T) {
+		e.POST("/tags", tc.Create)
+
+		createTagRequest, _ := json.Marshal(tags.TagRequest{
+			Name: "  tags   TEST ",
+		})
+
+		req := httptest.NewRequest(echo.POST, "/tags", bytes.NewBuffer(createTagRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusConflict, response.Code)
+	})
+
+	t.Run("Create tags sharing a slug", func(t *testing.T) {
+		e.POST("/tags", tc.Create)
+
+		for _, name := range []string{"C++", "C#"} {
+			createTagRequest, _ := json.Marshal(tags.TagRequest{
+				Name: name,
+			})
+
+			req := httptest.NewRequest(echo.POST, "/tags", bytes.NewBuffer(createTagRequest))
+			req.Header.Set("Content-Type", "application/json")
+
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			var response common.DefaultResponse
+			json.Unmarshal(rec.Body.Bytes(), &response)
+
+			assert.Equal(t, http.StatusOK, response.Code)
+		}
+
+		var slugs []string
+		db.Model(&entity.Tag{}).Where("name IN ?", []string{"C++", "C#"}).Order("id").Pluck("slug", &slugs)
+		assert.Equal(t, []string{"c", "c-2"}, slugs)
+	})
+
 	t.Run("Create tag bad request", func(t *testing.T) {
 		e.POST("/tags", tc.Create)
 
@@ -76,6 +127,8 @@ func TestGetTag(t *testing.T) {
 
 	e.Validator = &common.Validator{Validator: validator.New()}
 
+	e.HTTPErrorHandler = common.ErrorHandler
+
 	tr := repository.NewTagRepository(db)
 
 	tc := tags.NewTagController(tr)
@@ -94,6 +147,125 @@ func TestGetTag(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, response.Code)
 	})
+
+	t.Run("Get All tag with status success", func(t *testing.T) {
+		e.GET("/tags", tc.ReadAll)
+
+		req := httptest.NewRequest(echo.GET, "/tags?status=publish", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "database", response.Source)
+	})
+
+	t.Run("Get All tag with draft status unauthorized", func(t *testing.T) {
+		e.GET("/tags", tc.ReadAll)
+
+		req := httptest.NewRequest(echo.GET, "/tags?status=draft", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	})
+
+	t.Run("Get All tag counts published news only", func(t *testing.T) {
+		nr := repository.NewNewsRepository(db)
+
+		tag, _ := tr.Create(entity.Tag{Name: "Counted Topic"})
+
+		draft, _ := nr.Create(entity.News{Title: "Counted Draft", Body: "Counted Body"}, entity.TagSelection{IDs: []int{int(tag.ID)}}, nil)
+		defer nr.Purge(int(draft.ID))
+
+		published, _ := nr.Create(entity.News{Title: "Counted News", Body: "Counted Body"}, entity.TagSelection{IDs: []int{int(tag.ID)}}, nil)
+		defer nr.Purge(int(published.ID))
+		nr.SetStatusPublish(int(published.ID), "")
+
+		services.DeleteCache(0, "tag")
+
+		e.GET("/tags", tc.ReadAll)
+
+		req := httptest.NewRequest(echo.GET, "/tags", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		counted := false
+
+		data, _ := response.Data.([]interface{})
+		for _, item := range data {
+			if item := item.(map[string]interface{}); item["id"] == float64(tag.ID) {
+				counted = true
+				assert.Equal(t, float64(1), item["count"])
+			}
+		}
+
+		assert.True(t, counted)
+	})
+
+	t.Run("Get popular tag success", func(t *testing.T) {
+		services.RebuildTagPopularity(0, repository.NewNewsRepository(db))
+
+		e.GET("/tags/popular", tc.ReadPopular)
+
+		req := httptest.NewRequest(echo.GET, "/tags/popular?limit=3", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, 3, len(response.Data.([]interface{})))
+	})
+
+	t.Run("Get popular tag bad request", func(t *testing.T) {
+		e.GET("/tags/popular", tc.ReadPopular)
+
+		req := httptest.NewRequest(echo.GET, "/tags/popular?limit=abc", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Get tag tree success", func(t *testing.T) {
+		e.GET("/tags/tree", tc.ReadTree)
+
+		req := httptest.NewRequest(echo.GET, "/tags/tree", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
 }
 
 func TestEditTag(t *testing.T) {
@@ -104,6 +276,8 @@ func TestEditTag(t *testing.T) {
 
 	e.Validator = &common.Validator{Validator: validator.New()}
 
+	e.HTTPErrorHandler = common.ErrorHandler
+
 	tr := repository.NewTagRepository(db)
 
 	tc := tags.NewTagController(tr)
@@ -112,7 +286,8 @@ func TestEditTag(t *testing.T) {
 		e.PUT("/tags/:id", tc.Edit)
 
 		updateTagRequest, _ := json.Marshal(tags.TagRequest{
-			Name: "Tags Test",
+			Name:    "Tags Test Edited",
+			Version: 1,
 		})
 
 		req := httptest.NewRequest(echo.PUT, "/tags/1", bytes.NewBuffer(updateTagRequest))
@@ -128,6 +303,74 @@ func TestEditTag(t *testing.T) {
 		assert.Equal(t, http.StatusOK, response.Code)
 	})
 
+	t.Run("Edit tag stale version", func(t *testing.T) {
+		e.PUT("/tags/:id", tc.Edit)
+
+		updateTagRequest, _ := json.Marshal(tags.TagRequest{
+			Name:    "Tags Test Stale",
+			Version: 1,
+		})
+
+		req := httptest.NewRequest(echo.PUT, "/tags/1", bytes.NewBuffer(updateTagRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusConflict, response.Code)
+	})
+
+	parentID := uint(6)
+	childID := uint(5)
+
+	t.Run("Edit tag parent success", func(t *testing.T) {
+		e.PUT("/tags/:id", tc.Edit)
+
+		updateTagRequest, _ := json.Marshal(tags.TagRequest{
+			Name:     "Topic5",
+			ParentID: &parentID,
+			Version:  1,
+		})
+
+		req := httptest.NewRequest(echo.PUT, "/tags/5", bytes.NewBuffer(updateTagRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Edit tag parent cycle", func(t *testing.T) {
+		e.PUT("/tags/:id", tc.Edit)
+
+		updateTagRequest, _ := json.Marshal(tags.TagRequest{
+			Name:     "Topic6",
+			ParentID: &childID,
+			Version:  1,
+		})
+
+		req := httptest.NewRequest(echo.PUT, "/tags/6", bytes.NewBuffer(updateTagRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
 	t.Run("Edit tag bad request validator", func(t *testing.T) {
 		e.PUT("/tags/:id", tc.Edit)
 
@@ -168,7 +411,8 @@ func TestEditTag(t *testing.T) {
 		e.PUT("/tags/:id", tc.Edit)
 
 		updateTagRequest, _ := json.Marshal(tags.TagRequest{
-			Name: "Test Topic",
+			Name:    "Test Topic",
+			Version: 1,
 		})
 
 		req := httptest.NewRequest(echo.PUT, "/tags/9999", bytes.NewBuffer(updateTagRequest))
@@ -185,6 +429,113 @@ func TestEditTag(t *testing.T) {
 	})
 }
 
+func TestMergeTag(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	tr := repository.NewTagRepository(db)
+
+	tc := tags.NewTagController(tr)
+
+	t.Run("Merge tag success", func(t *testing.T) {
+		e.POST("/tags/:id/merge", tc.Merge)
+
+		mergeTagRequest, _ := json.Marshal(tags.MergeRequest{
+			SourceIDs: []int{3, 4},
+			Name:      "Topic Merged",
+		})
+
+		req := httptest.NewRequest(echo.POST, "/tags/2/merge", bytes.NewBuffer(mergeTagRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		var count int64
+		db.Model(&entity.NewsTags{}).Where("tag_id IN ?", []int{3, 4}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("Merge tag unknown source", func(t *testing.T) {
+		e.POST("/tags/:id/merge", tc.Merge)
+
+		mergeTagRequest, _ := json.Marshal(tags.MergeRequest{
+			SourceIDs: []int{3},
+		})
+
+		req := httptest.NewRequest(echo.POST, "/tags/2/merge", bytes.NewBuffer(mergeTagRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+	})
+
+	t.Run("Merge tag renamed to a source", func(t *testing.T) {
+		target, _ := tr.Create(entity.Tag{Name: "Merge Target"})
+		source, _ := tr.Create(entity.Tag{Name: "Merge Source"})
+
+		e.POST("/tags/:id/merge", tc.Merge)
+
+		mergeTagRequest, _ := json.Marshal(tags.MergeRequest{
+			SourceIDs: []int{int(source.ID)},
+			Name:      "Merge Source",
+		})
+
+		req := httptest.NewRequest(echo.POST, fmt.Sprintf("/tags/%d/merge", target.ID), bytes.NewBuffer(mergeTagRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		var merged entity.Tag
+		db.First(&merged, target.ID)
+		assert.Equal(t, "Merge Source", merged.Name)
+		assert.Equal(t, "merge-source", merged.Slug)
+	})
+
+	t.Run("Merge tag bad request validator", func(t *testing.T) {
+		e.POST("/tags/:id/merge", tc.Merge)
+
+		mergeTagRequest, _ := json.Marshal(tags.MergeRequest{})
+
+		req := httptest.NewRequest(echo.POST, "/tags/2/merge", bytes.NewBuffer(mergeTagRequest))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
+
 func TestDeleteTag(t *testing.T) {
 	config := config.GetConfig()
 	db := utils.InitDB(config)
@@ -193,6 +544,8 @@ func TestDeleteTag(t *testing.T) {
 
 	e.Validator = &common.Validator{Validator: validator.New()}
 
+	e.HTTPErrorHandler = common.ErrorHandler
+
 	tr := repository.NewTagRepository(db)
 
 	tc := tags.NewTagController(tr)
@@ -241,4 +594,64 @@ func TestDeleteTag(t *testing.T) {
 
 		assert.Equal(t, http.StatusNotFound, response.Code)
 	})
+
+	t.Run("Get trash tag success", func(t *testing.T) {
+		e.GET("/tags/trash", tc.ReadTrash)
+
+		req := httptest.NewRequest(echo.GET, "/tags/trash", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Restore tag success", func(t *testing.T) {
+		e.POST("/tags/:id/restore", tc.Restore)
+
+		req := httptest.NewRequest(echo.POST, "/tags/1/restore", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Hard delete tag success", func(t *testing.T) {
+		e.DELETE("/tags/:id", tc.Delete)
+
+		req := httptest.NewRequest(echo.DELETE, "/tags/1?hard=true", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Restore purged tag not found", func(t *testing.T) {
+		e.POST("/tags/:id/restore", tc.Restore)
+
+		req := httptest.NewRequest(echo.POST, "/tags/1/restore", nil)
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.DefaultResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
 }
@@ -0,0 +1,190 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	config "github.com/furqonzt99/news-redis/configs"
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/furqonzt99/news-redis/delivery/controllers/news"
+	"github.com/furqonzt99/news-redis/delivery/middlewares"
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/furqonzt99/news-redis/services"
+	"github.com/furqonzt99/news-redis/utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenant(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	tnr := repository.NewTenantRepository(db)
+
+	other, err := tnr.Create(entity.Tenant{Name: "Other", Slug: "other", Host: "other.example.com"})
+	assert.Nil(t, err)
+
+	middlewares.TenantMiddleware(e, tnr)
+
+	e.Use(actAs(entity.RoleAdmin))
+
+	nr := repository.NewNewsRepository(db)
+	tr := repository.NewTagRepository(db)
+
+	nc := news.NewNewsController(nr)
+
+	// tags are unique within a tenant only
+	_, err = tr.ForTenant(other.ID).Create(entity.Tag{Name: "Topic1"})
+	assert.Nil(t, err)
+
+	send := func(method, path string, body interface{}, header, value string) common.ResponseSuccess {
+		payload, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		if header == "Host" {
+			req.Host = value
+		} else if header != "" {
+			req.Header.Set(header, value)
+		}
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		return response
+	}
+
+	t.Run("Create news with tags of another tenant", func(t *testing.T) {
+		e.POST("/news", nc.Create)
+
+		response := send(echo.POST, "/news", news.CreateNewsRequest{
+			Title: "Other Title",
+			Body:  "Other Body",
+			Tags:  []int{1},
+		}, middlewares.TenantHeader, "other")
+
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+	})
+
+	t.Run("Create news with tags of the tenant", func(t *testing.T) {
+		e.POST("/news", nc.Create)
+
+		response := send(echo.POST, "/news", news.CreateNewsRequest{
+			Title:    "Other Title",
+			Body:     "Other Body",
+			TagNames: []string{"Topic1"},
+		}, middlewares.TenantHeader, "other")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Get all news of the tenant by header", func(t *testing.T) {
+		e.GET("/news", nc.ReadAll)
+
+		response := send(echo.GET, "/news", nil, middlewares.TenantHeader, "other")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data, _ := response.Data.([]interface{})
+		assert.Equal(t, 1, len(data))
+	})
+
+	t.Run("Get all news of the tenant by host", func(t *testing.T) {
+		e.GET("/news", nc.ReadAll)
+
+		response := send(echo.GET, "/news", nil, "Host", "other.example.com:8080")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data, _ := response.Data.([]interface{})
+		assert.Equal(t, 1, len(data))
+	})
+
+	t.Run("Get one news of another tenant not found", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne)
+
+		response := send(echo.GET, "/news/1", nil, middlewares.TenantHeader, "other")
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("Get all news of the default tenant", func(t *testing.T) {
+		e.GET("/news", nc.ReadAll)
+
+		response := send(echo.GET, "/news", nil, "", "")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data, _ := response.Data.([]interface{})
+		for _, item := range data {
+			assert.NotEqual(t, "Other Title", item.(map[string]interface{})["title"])
+		}
+	})
+
+	t.Run("Unknown tenant not found", func(t *testing.T) {
+		e.GET("/news", nc.ReadAll)
+
+		response := send(echo.GET, "/news", nil, middlewares.TenantHeader, "unknown")
+
+		assert.Equal(t, http.StatusNotFound, response.Code)
+	})
+
+	t.Run("API key used on another tenant forbidden", func(t *testing.T) {
+		kr := repository.NewAPIKeyRepository(db)
+
+		key, _ := services.GenerateAPIKey()
+
+		_, err := kr.Create(entity.APIKey{Name: "default", Hash: services.HashAPIKey(key), Scopes: string(entity.ScopeNewsRead)})
+		assert.Nil(t, err)
+
+		e.GET("/whoami", func(c echo.Context) error {
+			return c.JSON(http.StatusOK, common.SuccessResponseWithData(common.Actor(c), "database"))
+		}, middlewares.AuthMiddleware(kr))
+
+		whoami := func(tenant string) int {
+			req := httptest.NewRequest(echo.GET, "/whoami", nil)
+			req.Header.Set(echo.HeaderAuthorization, "ApiKey "+key)
+			if tenant != "" {
+				req.Header.Set(middlewares.TenantHeader, tenant)
+			}
+
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			return rec.Code
+		}
+
+		assert.Equal(t, http.StatusOK, whoami(""))
+		assert.Equal(t, http.StatusForbidden, whoami("other"))
+	})
+
+	t.Run("Responses vary by tenant", func(t *testing.T) {
+		e.GET("/news", nc.ReadAll)
+
+		req := httptest.NewRequest(echo.GET, "/news", nil)
+		req.Header.Set(middlewares.TenantHeader, "other")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Values(echo.HeaderVary), middlewares.TenantHeader)
+	})
+}
@@ -0,0 +1,173 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	config "github.com/furqonzt99/news-redis/configs"
+	"github.com/furqonzt99/news-redis/delivery/common"
+	"github.com/furqonzt99/news-redis/delivery/controllers/news"
+	"github.com/furqonzt99/news-redis/delivery/controllers/tags"
+	"github.com/furqonzt99/news-redis/delivery/middlewares"
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/furqonzt99/news-redis/domain/repository"
+	"github.com/furqonzt99/news-redis/utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslation(t *testing.T) {
+	config := config.GetConfig()
+	db := utils.InitDB(config)
+
+	e := echo.New()
+
+	e.Validator = &common.Validator{Validator: validator.New()}
+
+	e.HTTPErrorHandler = common.ErrorHandler
+
+	e.Use(actAs(entity.RoleAdmin))
+
+	nr := repository.NewNewsRepository(db)
+	tr := repository.NewTagRepository(db)
+
+	nc := news.NewNewsController(nr)
+	tc := tags.NewTagController(tr)
+
+	tag, _ := tr.Create(entity.Tag{Name: "Translated Topic"})
+
+	translated, _ := nr.Create(entity.News{
+		Title: "Translated Title",
+		Body:  "Translated Body",
+	}, entity.TagSelection{IDs: []int{int(tag.ID)}}, nil)
+
+	newsPath := fmt.Sprintf("/news/%d", translated.ID)
+
+	send := func(method, path string, body interface{}, acceptLanguage string) common.ResponseSuccess {
+		payload, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var response common.ResponseSuccess
+		json.Unmarshal(rec.Body.Bytes(), &response)
+
+		return response
+	}
+
+	t.Run("Translate news to the default locale", func(t *testing.T) {
+		e.PUT("/news/:id/translations/:locale", nc.SaveTranslation)
+
+		response := send(echo.PUT, newsPath+"/translations/"+common.DefaultLocale(), news.TranslationRequest{
+			Title: "Title",
+			Body:  "Body",
+		}, "")
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("Translate news", func(t *testing.T) {
+		e.PUT("/news/:id/translations/:locale", nc.SaveTranslation)
+
+		response := send(echo.PUT, newsPath+"/translations/en", news.TranslationRequest{
+			Title: "English Title",
+			Body:  "English Body",
+		}, "")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Translate tag", func(t *testing.T) {
+		e.PUT("/tags/:id/translations/:locale", tc.SaveTranslation)
+
+		response := send(echo.PUT, fmt.Sprintf("/tags/%d/translations/en", tag.ID), tags.TranslationRequest{
+			Name: "English Topic",
+		}, "")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Get news translations", func(t *testing.T) {
+		e.GET("/news/:id/translations", nc.ReadTranslations)
+
+		response := send(echo.GET, newsPath+"/translations", nil, "")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data, _ := response.Data.([]interface{})
+		assert.Equal(t, 1, len(data))
+	})
+
+	t.Run("Get translated news by lang", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne)
+
+		response := send(echo.GET, newsPath+"?lang=en", nil, "")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data, _ := response.Data.(map[string]interface{})
+		assert.Equal(t, "English Title", data["title"])
+		assert.Equal(t, "en", data["locale"])
+		tags, _ := data["tags"].([]interface{})
+		if assert.Len(t, tags, 1) {
+			assert.Equal(t, "English Topic", tags[0].(map[string]interface{})["name"])
+		}
+	})
+
+	t.Run("Get translated news by Accept-Language", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne)
+
+		response := send(echo.GET, newsPath, nil, "fr-FR,en-US;q=0.8")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data, _ := response.Data.(map[string]interface{})
+		assert.Equal(t, "English Title", data["title"])
+	})
+
+	t.Run("Get news in the default locale", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne)
+
+		response := send(echo.GET, newsPath, nil, "")
+
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		data, _ := response.Data.(map[string]interface{})
+		assert.NotEqual(t, "English Title", data["title"])
+		assert.Equal(t, common.DefaultLocale(), data["locale"])
+	})
+
+	t.Run("Get news varies by locale", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne, middlewares.VaryMiddleware(middlewares.ReadVary...))
+
+		req := httptest.NewRequest(echo.GET, newsPath, nil)
+		req.Header.Set("Accept-Language", "en")
+
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.ElementsMatch(t, []string{"Accept-Language", "Authorization", "X-Tenant"}, rec.Header().Values(echo.HeaderVary))
+	})
+
+	t.Run("Get news in an unsupported lang", func(t *testing.T) {
+		e.GET("/news/:id", nc.ReadOne)
+
+		response := send(echo.GET, newsPath+"?lang=xx", nil, "")
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
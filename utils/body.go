@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/furqonzt99/news-redis/domain/entity"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+const (
+	// ExcerptWords is the length of the excerpt made of a body without summary
+	ExcerptWords = 50
+
+	// WordsPerMinute is the reading speed reading times are estimated with
+	WordsPerMinute = 200
+)
+
+var (
+	// htmlPolicy is the allow-list of tags and attributes a body may keep
+	htmlPolicy = bluemonday.UGCPolicy()
+
+	markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	blankLines = regexp.MustCompile(`\n\s*\n`)
+	htmlTags   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// SanitizeHTML removes the tags and attributes which are not allow-listed,
+// such as scripts, styles and event handlers
+func SanitizeHTML(body string) string {
+	return strings.TrimSpace(htmlPolicy.Sanitize(body))
+}
+
+// RenderBody turns a body written in a format into HTML safe to show
+func RenderBody(format entity.BodyFormat, body string) (string, error) {
+	switch format {
+	case entity.FormatMarkdown:
+		var buffer bytes.Buffer
+
+		if err := markdown.Convert([]byte(body), &buffer); err != nil {
+			return "", err
+		}
+
+		return SanitizeHTML(buffer.String()), nil
+	case entity.FormatHTML:
+		return SanitizeHTML(body), nil
+	}
+
+	return renderPlain(body), nil
+}
+
+// renderPlain escapes a plain text body and keeps its paragraphs and line
+// breaks
+func renderPlain(body string) string {
+	body = strings.ReplaceAll(strings.TrimSpace(body), "\r\n", "\n")
+
+	paragraphs := []string{}
+
+	for _, paragraph := range blankLines.Split(body, -1) {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			lines := strings.Split(html.EscapeString(paragraph), "\n")
+			paragraphs = append(paragraphs, "<p>"+strings.Join(lines, "<br>")+"</p>")
+		}
+	}
+
+	return strings.Join(paragraphs, "\n")
+}
+
+// BodyWords returns the words of a rendered body
+func BodyWords(bodyHTML string) []string {
+	return strings.Fields(html.UnescapeString(htmlTags.ReplaceAllString(bodyHTML, " ")))
+}
+
+// Excerpt returns the summary of a body when it has one, or else its first
+// words
+func Excerpt(summary string, words []string) string {
+	if summary = strings.TrimSpace(summary); summary != "" {
+		return summary
+	}
+
+	if len(words) <= ExcerptWords {
+		return strings.Join(words, " ")
+	}
+
+	return strings.Join(words[:ExcerptWords], " ") + "…"
+}
+
+// ReadingTime estimates the minutes it takes to read a number of words,
+// a body with any word takes at least a minute
+func ReadingTime(wordCount int) int {
+	return (wordCount + WordsPerMinute - 1) / WordsPerMinute
+}
@@ -0,0 +1,100 @@
+package utils
+
+import "strings"
+
+// MaxDiffCells bounds the lines DiffLines compares, past it the changed lines
+// are shown as all removed and then all added
+const MaxDiffCells = 1000000
+
+// DiffLines compares two texts line by line. Unchanged lines are prefixed
+// with "  ", removed lines with "- " and added lines with "+ ".
+func DiffLines(from, to string) []string {
+	a := strings.Split(from, "\n")
+	b := strings.Split(to, "\n")
+
+	// the lines both texts start or end with are kept out of the comparison
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := []string{}
+
+	for _, line := range a[:prefix] {
+		lines = append(lines, "  "+line)
+	}
+
+	lines = append(lines, diffChanged(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, "  "+line)
+	}
+
+	return lines
+}
+
+// diffChanged compares the lines between the common prefix and suffix of two
+// texts with their longest common subsequence
+func diffChanged(a, b []string) []string {
+	lines := []string{}
+
+	if (len(a)+1)*(len(b)+1) > MaxDiffCells {
+		for _, line := range a {
+			lines = append(lines, "- "+line)
+		}
+
+		for _, line := range b {
+			lines = append(lines, "+ "+line)
+		}
+
+		return lines
+	}
+
+	// lcs[i*width+j] is the longest common subsequence of a[i:] and b[j:]
+	width := len(b) + 1
+	lcs := make([]int, (len(a)+1)*width)
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else if lcs[(i+1)*width+j] >= lcs[i*width+j+1] {
+				lcs[i*width+j] = lcs[(i+1)*width+j]
+			} else {
+				lcs[i*width+j] = lcs[i*width+j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i++
+			j++
+		case lcs[(i+1)*width+j] >= lcs[i*width+j+1]:
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		lines = append(lines, "- "+a[i])
+	}
+
+	for ; j < len(b); j++ {
+		lines = append(lines, "+ "+b[j])
+	}
+
+	return lines
+}
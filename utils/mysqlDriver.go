@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"fmt"
+
 	config "github.com/furqonzt99/news-redis/configs"
 	"github.com/furqonzt99/news-redis/domain/entity"
 	"gorm.io/driver/mysql"
@@ -21,5 +23,249 @@ func InitDB(config *config.AppConfig) *gorm.DB {
 }
 
 func InitialMigrate(db *gorm.DB) {
-	db.AutoMigrate(&entity.Tag{}, &entity.News{})
+	backfillTagSlugs(db)
+	backfillNewsSlugs(db)
+	dropGlobalIndexes(db)
+	backfillTagNameKeys(db)
+	dropUniqueTagSlugIndex(db)
+	backfillBodyHTML(db)
+	backfillReadingStats(db)
+	backfillPublishedAt(db)
+
+	db.AutoMigrate(&entity.Tenant{}, &entity.User{}, &entity.APIKey{}, &entity.Tag{}, &entity.News{}, &entity.NewsTags{}, &entity.NewsSlug{}, &entity.NewsStatusHistory{}, &entity.NewsRevision{}, &entity.NewsTranslation{}, &entity.TagTranslation{})
+
+	createDefaultTenant(db)
+}
+
+// dropGlobalIndexes drops the unique indexes from before tenants, slugs and
+// usernames are now only unique within a tenant
+func dropGlobalIndexes(db *gorm.DB) {
+	indexes := []struct {
+		model interface{}
+		name  string
+	}{
+		{&entity.Tag{}, "idx_tags_slug"},
+		{&entity.News{}, "idx_news_slug"},
+		{&entity.NewsSlug{}, "idx_news_slugs_slug"},
+		{&entity.User{}, "idx_users_username"},
+	}
+
+	for _, index := range indexes {
+		if db.Migrator().HasTable(index.model) && db.Migrator().HasIndex(index.model, index.name) {
+			if err := db.Migrator().DropIndex(index.model, index.name); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// backfillTagNameKeys gives existing tags the name key the unique index is on
+// before it is created. Names are normalized like the names of new tags, and
+// a tag whose name then matches an older one of its tenant gets a number.
+func backfillTagNameKeys(db *gorm.DB) {
+	if !db.Migrator().HasTable(&entity.Tag{}) || db.Migrator().HasColumn(&entity.Tag{}, "NameKey") {
+		return
+	}
+
+	if err := db.Migrator().AddColumn(&entity.Tag{}, "NameKey"); err != nil {
+		panic(err)
+	}
+
+	var tags []entity.Tag
+	db.Unscoped().Order("id").Find(&tags)
+
+	used := map[string]bool{}
+
+	for _, tag := range tags {
+		name := NormalizeName(tag.Name)
+
+		for i := 2; used[fmt.Sprint(tag.TenantID, ":", NameKey(name))]; i++ {
+			name = fmt.Sprint(NormalizeName(tag.Name), " ", i)
+		}
+
+		used[fmt.Sprint(tag.TenantID, ":", NameKey(name))] = true
+
+		if err := db.Unscoped().Model(&tag).UpdateColumns(map[string]interface{}{"name": name, "name_key": NameKey(name)}).Error; err != nil {
+			panic(err)
+		}
+	}
+}
+
+// dropUniqueTagSlugIndex drops the unique index tag slugs had, tag names are
+// unique now and a slug gets a number when another tag has it
+func dropUniqueTagSlugIndex(db *gorm.DB) {
+	var nonUnique []int
+
+	db.Raw("SELECT non_unique FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?",
+		"tags", "idx_tags_tenant_slug").Scan(&nonUnique)
+
+	if len(nonUnique) > 0 && nonUnique[0] == 0 {
+		if err := db.Migrator().DropIndex(&entity.Tag{}, "idx_tags_tenant_slug"); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// createDefaultTenant creates the tenant owning the news and tags created
+// before tenants existed
+func createDefaultTenant(db *gorm.DB) {
+	db.FirstOrCreate(&entity.Tenant{
+		Model: gorm.Model{ID: entity.DefaultTenantID},
+		Name:  "Default",
+		Slug:  "default",
+	})
+}
+
+// backfillTagSlugs gives existing tags a slug when the column is added
+func backfillTagSlugs(db *gorm.DB) {
+	if !db.Migrator().HasTable(&entity.Tag{}) || db.Migrator().HasColumn(&entity.Tag{}, "Slug") {
+		return
+	}
+
+	if err := db.Migrator().AddColumn(&entity.Tag{}, "Slug"); err != nil {
+		panic(err)
+	}
+
+	var tags []entity.Tag
+	db.Unscoped().Order("id").Find(&tags)
+
+	used := map[string]bool{}
+	taken := func(slug string) (bool, error) { return used[slug], nil }
+
+	for _, tag := range tags {
+		slug, _ := UniqueSlug(tag.Name, "tag", taken)
+		used[slug] = true
+
+		db.Unscoped().Model(&tag).UpdateColumn("slug", slug)
+	}
+}
+
+// backfillBodyHTML renders the bodies of existing news and translations,
+// which are all plain text since they predate body formats
+func backfillBodyHTML(db *gorm.DB) {
+	if db.Migrator().HasTable(&entity.News{}) && !db.Migrator().HasColumn(&entity.News{}, "BodyHTML") {
+		for _, column := range []string{"BodyFormat", "BodyHTML"} {
+			if err := db.Migrator().AddColumn(&entity.News{}, column); err != nil {
+				panic(err)
+			}
+		}
+
+		var news []entity.News
+		db.Unscoped().Select("id", "body").Order("id").Find(&news)
+
+		for _, item := range news {
+			rendered, _ := RenderBody(entity.FormatPlain, item.Body)
+
+			db.Unscoped().Model(&item).UpdateColumn("body_html", rendered)
+		}
+	}
+
+	if db.Migrator().HasTable(&entity.NewsTranslation{}) && !db.Migrator().HasColumn(&entity.NewsTranslation{}, "BodyHTML") {
+		if err := db.Migrator().AddColumn(&entity.NewsTranslation{}, "BodyHTML"); err != nil {
+			panic(err)
+		}
+
+		var translations []entity.NewsTranslation
+		db.Select("id", "body").Order("id").Find(&translations)
+
+		for _, translation := range translations {
+			rendered, _ := RenderBody(entity.FormatPlain, translation.Body)
+
+			db.Model(&translation).UpdateColumn("body_html", rendered)
+		}
+	}
+}
+
+// backfillReadingStats works out the excerpt, word count and reading time of
+// existing news and translations from their rendered body
+func backfillReadingStats(db *gorm.DB) {
+	columns := []string{"Excerpt", "WordCount", "ReadingTime"}
+
+	if db.Migrator().HasTable(&entity.News{}) && !db.Migrator().HasColumn(&entity.News{}, "Excerpt") {
+		for _, column := range append(columns, "Summary") {
+			if err := db.Migrator().AddColumn(&entity.News{}, column); err != nil {
+				panic(err)
+			}
+		}
+
+		var news []entity.News
+		db.Unscoped().Select("id", "body_html").Order("id").Find(&news)
+
+		for _, item := range news {
+			words := BodyWords(item.BodyHTML)
+
+			db.Unscoped().Model(&item).UpdateColumns(map[string]interface{}{
+				"excerpt":      Excerpt("", words),
+				"word_count":   len(words),
+				"reading_time": ReadingTime(len(words)),
+			})
+		}
+	}
+
+	if db.Migrator().HasTable(&entity.NewsTranslation{}) && !db.Migrator().HasColumn(&entity.NewsTranslation{}, "Excerpt") {
+		for _, column := range append(columns, "Summary") {
+			if err := db.Migrator().AddColumn(&entity.NewsTranslation{}, column); err != nil {
+				panic(err)
+			}
+		}
+
+		var translations []entity.NewsTranslation
+		db.Select("id", "body_html").Order("id").Find(&translations)
+
+		for _, translation := range translations {
+			words := BodyWords(translation.BodyHTML)
+
+			db.Model(&translation).UpdateColumns(map[string]interface{}{
+				"excerpt":      Excerpt("", words),
+				"word_count":   len(words),
+				"reading_time": ReadingTime(len(words)),
+			})
+		}
+	}
+}
+
+// backfillPublishedAt sets when published news were last published, from
+// their status history or else their last update
+func backfillPublishedAt(db *gorm.DB) {
+	if !db.Migrator().HasTable(&entity.News{}) || db.Migrator().HasColumn(&entity.News{}, "PublishedAt") {
+		return
+	}
+
+	if err := db.Migrator().AddColumn(&entity.News{}, "PublishedAt"); err != nil {
+		panic(err)
+	}
+
+	if db.Migrator().HasTable(&entity.NewsStatusHistory{}) {
+		db.Exec(`UPDATE news SET published_at = (
+			SELECT MAX(created_at) FROM news_status_histories
+			WHERE news_status_histories.news_id = news.id AND news_status_histories.to_status = ?
+		) WHERE status = ?`, entity.StatusPublish, entity.StatusPublish)
+	}
+
+	db.Exec("UPDATE news SET published_at = updated_at WHERE status = ? AND published_at IS NULL", entity.StatusPublish)
+}
+
+// backfillNewsSlugs gives existing news a slug, cut like the slugs of new
+// news, before the index making them unique within a tenant is created
+func backfillNewsSlugs(db *gorm.DB) {
+	if !db.Migrator().HasTable(&entity.News{}) || db.Migrator().HasColumn(&entity.News{}, "Slug") {
+		return
+	}
+
+	if err := db.Migrator().AddColumn(&entity.News{}, "Slug"); err != nil {
+		panic(err)
+	}
+
+	var news []entity.News
+	db.Unscoped().Select("id", "title").Order("id").Find(&news)
+
+	used := map[string]bool{}
+	taken := func(slug string) (bool, error) { return used[slug], nil }
+
+	for _, item := range news {
+		slug, _ := UniqueSlug(TruncateSlug(Slugify(item.Title), entity.MaxNewsSlugLength), "news", taken)
+		used[slug] = true
+
+		db.Unscoped().Model(&item).UpdateColumn("slug", slug)
+	}
 }
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Slugify turns a text into a lowercase, dash separated URL slug
+func Slugify(text string) string {
+	var builder strings.Builder
+
+	dash := false
+
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && builder.Len() > 0 {
+				builder.WriteRune('-')
+			}
+
+			builder.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+
+	return builder.String()
+}
+
+// TruncateSlug cuts a slug to a number of runes, without a trailing dash
+func TruncateSlug(slug string, length int) string {
+	if runes := []rune(slug); len(runes) > length {
+		return strings.TrimRight(string(runes[:length]), "-")
+	}
+
+	return slug
+}
+
+// NormalizeName trims a name and collapses the whitespace inside it
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// NameKey is what names are compared by, regardless of case and whitespace
+func NameKey(name string) string {
+	return strings.ToLower(NormalizeName(name))
+}
+
+// UniqueSlug slugifies a text, falling back when nothing is left, and adds a
+// numeric suffix (-2, -3, ...) until taken reports the slug as free
+func UniqueSlug(text, fallback string, taken func(slug string) (bool, error)) (string, error) {
+	base := Slugify(text)
+	if base == "" {
+		base = fallback
+	}
+
+	slug := base
+
+	for i := 2; ; i++ {
+		used, err := taken(slug)
+		if err != nil {
+			return "", err
+		}
+
+		if !used {
+			return slug, nil
+		}
+
+		slug = base + "-" + fmt.Sprint(i)
+	}
+}